@@ -0,0 +1,87 @@
+package migra
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// PushZip pushes all migrations inside a zip archive, including those in nested directories, see PushFS
+func (m *Migra) PushZip(ctx context.Context, zipPath string) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+	return m.PushFS(ctx, r)
+}
+
+// PushTarGz pushes all migrations inside a gzip compressed tar archive, including those in nested directories, see PushFS
+func (m *Migra) PushTarGz(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	filesystem, err := tarGzFS(f)
+	if err != nil {
+		return err
+	}
+
+	return m.PushFS(ctx, filesystem)
+}
+
+// tarGzFS reads a gzip compressed tar archive into an in memory filesystem.
+// The regular files of the archive are rewritten as a zip archive, whose reader implements fs.FS.
+func tarGzFS(r io.Reader) (fs.FS, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	defer gz.Close()
+
+	var (
+		buf bytes.Buffer
+		tr  = tar.NewReader(gz)
+		zw  = zip.NewWriter(&buf)
+	)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		w, err := zw.Create(hdr.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := io.Copy(w, tr); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+}
@@ -0,0 +1,101 @@
+package migra_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/cristosal/migra"
+)
+
+// archiveFiles are the migrations written to test archives, the second one is nested in a subdirectory
+var archiveFiles = []struct {
+	name    string
+	content string
+}{
+	{"migrations/001_first.yml", "name: First\nup: SELECT 1\ndown: SELECT 1"},
+	{"migrations/nested/002_second.yml", "name: Second\nup: SELECT 2\ndown: SELECT 2"},
+}
+
+func TestPushZip(t *testing.T) {
+	m := getMigra(t)
+	zipPath := path.Join(t.TempDir(), "migrations.zip")
+
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zw := zip.NewWriter(f)
+	for _, file := range archiveFiles {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(file.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Close()
+
+	if err := m.PushZip(ctx, zipPath); err != nil {
+		t.Fatal(err)
+	}
+
+	assertArchivePushed(t, m)
+}
+
+func TestPushTarGz(t *testing.T) {
+	m := getMigra(t)
+	tarPath := path.Join(t.TempDir(), "migrations.tar.gz")
+
+	f, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, file := range archiveFiles {
+		hdr := &tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(file.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	if err := m.PushTarGz(ctx, tarPath); err != nil {
+		t.Fatal(err)
+	}
+
+	assertArchivePushed(t, m)
+}
+
+func assertArchivePushed(t *testing.T, m *migra.Migra) {
+	t.Helper()
+
+	migrations, err := m.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 2 || migrations[0].Name != "First" || migrations[1].Name != "Second" {
+		t.Fatalf("expected First and Second to be pushed got %v", migrations)
+	}
+}
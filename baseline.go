@@ -0,0 +1,75 @@
+package migra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoBaseline is returned by Apply when a baseline is set but the migration table has migrations without it, see SetBaseline
+var ErrNoBaseline = errors.New("baseline migration is missing")
+
+// SetBaseline sets a migration representing the schema that existed before adopting migra.
+// Apply, and the directory pushes using it, record the baseline at position 0 as applied, without executing it,
+// when the migration table is empty, and refuse to push migrations when the migration table has migrations but no baseline.
+// The recorded baseline is not reported as missing by Status or as ahead by DBAhead when it is not in the source.
+func (m *Migra) SetBaseline(baseline Migration) *Migra {
+	m.baseline = &baseline
+	return m
+}
+
+// isBaseline reports whether the normalized name is the name of the baseline, if set
+func (m *Migra) isBaseline(name string) bool {
+	return m.baseline != nil && m.normalizeName(m.baseline.Name) == name
+}
+
+// recordBaseline records the baseline, if set, in its own transaction on c, see ensureBaseline
+func (m *Migra) recordBaseline(ctx context.Context, c conn) error {
+	if m.baseline == nil {
+		return nil
+	}
+
+	tx, err := m.begin(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := m.ensureBaseline(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ensureBaseline records the baseline in an empty migration table,
+// returning ErrNoBaseline when the table has other migrations but no baseline.
+// A baseline recorded concurrently by another runner is kept.
+func (m *Migra) ensureBaseline(ctx context.Context, tx Execer) error {
+	name := m.normalizeName(m.baseline.Name)
+
+	var (
+		count, baselines int
+		stmt             = m.query("SELECT COUNT(*), COUNT(*) FILTER (WHERE {name} = $1) FROM {table}")
+	)
+
+	if err := tx.QueryRowContext(ctx, stmt, name).Scan(&count, &baselines); err != nil {
+		return err
+	}
+
+	if baselines > 0 {
+		return nil
+	}
+
+	if count > 0 {
+		return fmt.Errorf("%w: %s is not recorded but the migration table has %d migrations", ErrNoBaseline, name, count)
+	}
+
+	up, down := m.recordedSQL(m.baseline.Up, m.baseline.Down)
+	stmt = m.query(`INSERT INTO {table} ({name}, {description}, {up}, {down}, {checksum}, {irreversible}, {key}, {position}, {migrated_at}, {status})
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 0, NOW(), 'applied') ON CONFLICT ({name}) DO NOTHING`)
+
+	_, err := tx.ExecContext(ctx, stmt, name, m.baseline.Description, up, down, m.baseline.ComputeChecksum(), m.baseline.Irreversible, migrationKey(name))
+	return err
+}
@@ -0,0 +1,116 @@
+package migra
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// postgresDrivers are the names and packages of the PostgreSQL drivers, whose session settings are reset with RESET ALL
+var postgresDrivers = map[string]bool{
+	"pgx":      true,
+	"pgx/v5":   true,
+	"postgres": true,
+	"stdlib":   true,
+	"pq":       true,
+}
+
+// conn is the subset of methods shared by *sql.DB and *sql.Conn that is needed for pushing migrations
+type conn interface {
+	Execer
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// SetPreBatchSQL sets sql that is executed once before a batch of migrations is pushed by PushMany, PushDir or PushFS.
+// It runs on the same connection as the migrations, outside of their transactions, so session settings such as
+// statement_timeout apply to every migration in the batch. On PostgreSQL the settings are reset with RESET ALL once the batch is done,
+// and the connection is discarded when they can not be reset, unless within InTx where they last until the end of the bound transaction. If the pre batch sql fails no migrations are pushed.
+func (m *Migra) SetPreBatchSQL(sql string) *Migra {
+	m.preBatchSQL = sql
+	return m
+}
+
+// SetPostBatchSQL sets sql that is executed once after a batch of migrations was pushed, for example ANALYZE.
+// It only runs when every migration in the batch succeeded. Migrations already committed are not
+// reverted when the post batch sql fails.
+func (m *Migra) SetPostBatchSQL(sql string) *Migra {
+	m.postBatchSQL = sql
+	return m
+}
+
+// SetCheckpointEvery sets the number of migrations pushed per transaction by batch pushes such as PushMany and PushFS.
+// By default every migration is pushed in its own transaction. With a larger n, progress is committed every n migrations
+// and a failure rolls back only the migrations since the last checkpoint. Setting n to at least the number of migrations
+// pushes the whole batch atomically.
+func (m *Migra) SetCheckpointEvery(n int) *Migra {
+	m.checkpointEvery = n
+	return m
+}
+
+// batch runs fn on a single connection surrounded by the pre and post batch sql, holding the distributed lock if set.
+// Within InTx the pre and post batch sql run in the bound transaction, and since transactions begun by fn are
+// savepoints of it the database is passed to fn only to satisfy conn.
+func (m *Migra) batch(ctx context.Context, fn func(ctx context.Context, c conn) error) error {
+	return m.withDistributedLock(ctx, func(ctx context.Context) error {
+		return m.batchLocked(ctx, fn)
+	})
+}
+
+// batchLocked runs the batch once the distributed lock, if set, is held, see batch
+func (m *Migra) batchLocked(ctx context.Context, fn func(ctx context.Context, c conn) error) error {
+	var (
+		ex     Execer = m.tx
+		c      conn   = m.db
+		pinned *sql.Conn
+	)
+
+	if m.tx == nil {
+		var err error
+		if pinned, err = m.db.Conn(ctx); err != nil {
+			return err
+		}
+
+		defer pinned.Close()
+		ex, c = pinned, pinned
+	}
+
+	if m.execMiddleware != nil {
+		ex = m.wrap(ex)
+		c = &wrappedConn{Execer: m.wrap(c), conn: c}
+	}
+
+	if m.preBatchSQL != "" {
+		// session settings made by the pre batch sql must not leak into later users of the connection
+		if pinned != nil && postgresDrivers[m.driver] {
+			defer m.resetSession(ctx, ex, pinned)
+		}
+
+		if _, err := ex.ExecContext(ctx, m.preBatchSQL); err != nil {
+			return err
+		}
+	}
+
+	if err := fn(ctx, c); err != nil {
+		return err
+	}
+
+	if m.postBatchSQL != "" {
+		if _, err := ex.ExecContext(ctx, m.postBatchSQL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resetSession resets the session settings of the pinned connection of a batch,
+// discarding the connection instead of returning it to the pool when they can not be reset
+func (m *Migra) resetSession(ctx context.Context, ex Execer, pinned *sql.Conn) {
+	ctx = context.WithoutCancel(ctx)
+	if _, err := ex.ExecContext(ctx, "RESET ALL"); err != nil {
+		m.logf("discarding connection: resetting session settings failed: %v", err)
+		pinned.Raw(func(any) error {
+			return driver.ErrBadConn
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package migra
+
+import (
+	"context"
+	"fmt"
+)
+
+// BatchSpec describes a data migration which is executed in batches, such as a large backfill
+// which would hold locks for too long or bloat the write-ahead log when executed as one statement.
+// The sql is executed repeatedly with the batch size as its only parameter, $1, until it affects no rows,
+// so it must limit itself to the batch size and skip rows handled by earlier batches, for example:
+//
+//	UPDATE users SET email_lower = lower(email) WHERE id IN (SELECT id FROM users WHERE email_lower IS NULL LIMIT $1)
+type BatchSpec struct {
+	SQL  string `mapstructure:"sql"`
+	Size int    `mapstructure:"batch_size"`
+}
+
+// check validates the batch spec of the named migration, a nil spec is valid
+func (b *BatchSpec) check(name string) error {
+	if b == nil {
+		return nil
+	}
+
+	if b.SQL == "" {
+		return fmt.Errorf("migration %s: batch sql is required", name)
+	}
+
+	if b.Size < 1 {
+		return fmt.Errorf("migration %s: batch size must be positive", name)
+	}
+
+	return nil
+}
+
+// pushBatched pushes a migration with a batch spec. Each batch is committed on its own before the migration is pushed,
+// after which the up sql is executed and the migration is recorded in a single transaction.
+// Batched migrations are not rolled back as a unit: when a batch or the up sql fails, the batches already executed stay committed,
+// and pushing the migration again continues with the remaining rows. A failed batch records the migration with StatusFailed.
+func (m *Migra) pushBatched(ctx context.Context, c conn, migration *Migration) error {
+	name := m.normalizeName(migration.Name)
+	if migration.InEnvironment(m.environment) && !m.pushed(ctx, c, name) {
+		if err := m.checkNoTx(name); err != nil {
+			return err
+		}
+
+		if err := m.checkAccess(name, migration); err != nil {
+			return err
+		}
+
+		for i := 1; ; i++ {
+			res, err := c.ExecContext(ctx, migration.Batch.SQL, migration.Batch.Size)
+			if err != nil {
+				err = wrapExecError(name, i, migration.Batch.SQL, err)
+				m.observe().MigrationFailed(name, err)
+				return m.recordFailed(ctx, c, name, migration, err)
+			}
+
+			n, err := res.RowsAffected()
+			if err != nil {
+				return m.recordFailed(ctx, c, name, migration, err)
+			}
+
+			m.logf("migration %s: batch %d affected %d rows", name, i, n)
+			if n == 0 {
+				break
+			}
+		}
+
+		if err := m.clearFailed(ctx, c, name); err != nil {
+			return err
+		}
+	}
+
+	// the batches already ran, so the migration is recorded without splitting the transaction again
+	return m.commitTx(ctx, c, []Migration{*migration})
+}
@@ -1,31 +1,65 @@
 package main
 
 import (
-	"database/sql"
+	"bufio"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
 	"os"
+	"os/exec"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/cristosal/migra"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
 	// global options
 	driver           string
 	connectionString string
+	connectionFile   string
 	tableName        string
 	schemaName       string
 
 	// pop options
-	popUntil string
-	popAll   bool
+	popUntil  string
+	popAll    bool
+	popDryRun bool
+	popForce  bool
+	popYes    bool
+
+	// list options
+	listNoColor bool
+	listSince   string
+	listUntil   string
+
+	// current options
+	currentStrict bool
+
+	// sql options
+	sqlDown bool
+
+	// plan options
+	planOutput string
+
+	// migrations directory used by push and verify
+	dir string
 
 	// push options
-	pushDir string
+	pushTag  string
+	pushOnly []string
+	pushEnv  string
 
 	root = &cobra.Command{
 		Use:          "migra",
@@ -57,6 +91,16 @@ var (
 				return err
 			}
 
+			if popDryRun {
+				return planPop(cmd.Context(), m)
+			}
+
+			if err := confirmPop(os.Stdin, os.Stderr, isTerminal(os.Stdin)); err != nil {
+				return err
+			}
+
+			m.SetForceIrreversible(popForce)
+
 			if popAll {
 				n, err := m.PopAll(cmd.Context())
 				if err != nil {
@@ -84,13 +128,37 @@ var (
 		Aliases: []string{"add", "up"},
 		Short:   "Pushes a new migration",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			dirpath := getDir()
+			if dirpath == "" && migration.Up == "" {
+				return errors.New("nothing to push: use --dir, set MIGRA_DIR or dir in migra.yml, or pass an inline migration with --name and --up")
+			}
+
 			m, err := getMigra()
 			if err != nil {
 				return err
 			}
 
-			if pushDir != "" {
-				if err := m.PushDir(cmd.Context(), pushDir); err != nil {
+			m.SetEnvironment(getEnvironment())
+
+			if dirpath != "" {
+				migrations, err := migra.LoadDir(dirpath)
+				if err != nil {
+					return err
+				}
+
+				if err := m.CheckReconciled(cmd.Context(), migrations); err != nil {
+					return err
+				}
+
+				if len(pushOnly) > 0 {
+					err = m.PushNames(cmd.Context(), migrations, pushOnly...)
+				} else if pushTag != "" {
+					err = m.PushTagged(cmd.Context(), migrations, pushTag)
+				} else {
+					err = m.PushMany(cmd.Context(), migrations)
+				}
+
+				if err != nil {
 					return err
 				}
 			} else {
@@ -114,46 +182,362 @@ var (
 				return err
 			}
 
-			migrations, err := m.List(cmd.Context())
+			var report *migra.StatusReport
+			if listSince != "" || listUntil != "" {
+				since, err := parseTime(listSince)
+				if err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
+
+				until, err := parseTime(listUntil)
+				if err != nil {
+					return fmt.Errorf("invalid --until: %w", err)
+				}
+
+				applied, err := m.AppliedBetween(cmd.Context(), since, until)
+				if err != nil {
+					return err
+				}
+
+				report = &migra.StatusReport{Applied: applied}
+			} else if dirpath := getDir(); dirpath != "" {
+				source, err := migra.LoadDir(dirpath)
+				if err != nil {
+					return err
+				}
+
+				report, err = m.Status(cmd.Context(), source)
+				if err != nil {
+					return err
+				}
+			} else {
+				// without a source every applied migration is listed as applied
+				applied, err := m.List(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				report = &migra.StatusReport{Applied: applied}
+			}
+
+			if len(report.Applied)+len(report.Pending)+len(report.Missing) == 0 {
+				return errors.New("no migrations")
+			}
+
+			printStatus(os.Stdout, report, useColor())
+			return nil
+		},
+	}
+
+	current = &cobra.Command{
+		Use:     "current",
+		Aliases: []string{"latest"},
+		Short:   "Prints the latest applied migration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := getMigra()
 			if err != nil {
 				return err
 			}
 
-			if len(migrations) == 0 {
-				return errors.New("no migrations")
+			mig, err := m.Latest(cmd.Context())
+			if errors.Is(err, migra.ErrNoMigration) {
+				if currentStrict {
+					return errors.New("no migrations applied")
+				}
+
+				fmt.Println("no migrations applied")
+				return nil
+			}
+
+			if err != nil {
+				return err
 			}
 
+			fmt.Printf("Name: %s\n", mig.Name)
+			fmt.Printf("Position: %d\n", mig.Position)
+			fmt.Printf("Applied: %s\n", mig.MigratedAt.Format(time.RFC3339))
+			return nil
+		},
+	}
+
+	dump = &cobra.Command{
+		Use:   "dump",
+		Short: "Prints the DDL of the database schema",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := getMigra()
+			if err != nil {
+				return err
+			}
+
+			return m.DumpSchema(cmd.Context(), os.Stdout)
+		},
+	}
+
+	script = &cobra.Command{
+		Use:   "sql",
+		Short: "Prints a sql script of the pending migrations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dirpath := getDir()
+			if dirpath == "" {
+				return errors.New("no migrations directory: use --dir, set MIGRA_DIR or dir in migra.yml")
+			}
+
+			migrations, err := migra.LoadDir(dirpath)
+			if err != nil {
+				return err
+			}
+
+			m, err := getMigra()
+			if err != nil {
+				return err
+			}
+
+			var sql string
+			if sqlDown {
+				sql, err = m.PendingDownSQL(cmd.Context(), migrations)
+			} else {
+				sql, err = m.PendingSQL(cmd.Context(), migrations)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Print(sql)
+			return nil
+		},
+	}
+
+	plan = &cobra.Command{
+		Use:   "plan",
+		Short: "Prints the pending migrations without pushing them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dirpath := getDir()
+			if dirpath == "" {
+				return errors.New("no migrations directory: use --dir, set MIGRA_DIR or dir in migra.yml")
+			}
+
+			migrations, err := migra.LoadDir(dirpath)
+			if err != nil {
+				return err
+			}
+
+			m, err := getMigra()
+			if err != nil {
+				return err
+			}
+
+			m.SetEnvironment(getEnvironment())
+
+			p, err := m.Plan(cmd.Context(), migrations)
+			if err != nil {
+				return err
+			}
+
+			switch planOutput {
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(p)
+			case "text":
+				for _, entry := range p.Pending {
+					fmt.Println(entry.Name)
+				}
+
+				fmt.Printf("%d pending, %d applied\n", len(p.Pending), len(p.Applied))
+				return nil
+			default:
+				return fmt.Errorf("unknown output %q: use text or json", planOutput)
+			}
+		},
+	}
+
+	doctor = &cobra.Command{
+		Use:   "doctor",
+		Short: "Checks the database connection and migration table",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := getMigra()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Driver: %s\n", getDriver())
+
+			if err := m.DB().PingContext(cmd.Context()); err != nil {
+				return fmt.Errorf("ping failed: %w", err)
+			}
+
+			fmt.Println("Ping: ok")
+
+			version, err := m.ServerVersion(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Server version: %s\n", version)
+
+			exists, err := m.TableExists(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			if !exists {
+				fmt.Printf("Migration table: %s (missing, run migra init)\n", m.MigrationTable())
+				return nil
+			}
+
+			migrations, err := m.List(cmd.Context())
+			if err != nil {
+				fmt.Printf("Migration table: %s (unavailable: %v)\n", m.MigrationTable(), err)
+				return nil
+			}
+
+			var applied int
 			for i := range migrations {
-				mig := migrations[i]
-				fmt.Println("")
-				fmt.Printf("--- %d %s ---\n", mig.ID, mig.Name)
-				fmt.Printf("%s\n\n", mig.Description)
-				fmt.Printf("Up: %s\n", strings.Trim(mig.Up, " \t"))
-				fmt.Printf("Down: %s\n", strings.Trim(mig.Down, " \t"))
+				if !migrations[i].MigratedAt.IsZero() {
+					applied++
+				}
 			}
 
+			fmt.Printf("Migration table: %s\n", m.MigrationTable())
+			fmt.Printf("Applied migrations: %d\n", applied)
 			return nil
 		},
 	}
 
+	check = &cobra.Command{
+		Use:   "check",
+		Short: "Checks migration files without connecting to the database",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dirpath := getDir()
+			if dirpath == "" {
+				return errors.New("no migrations directory: use --dir, set MIGRA_DIR or dir in migra.yml")
+			}
+
+			errs := migra.CheckDir(dirpath)
+			if len(errs) == 0 {
+				fmt.Println("all migrations are valid")
+				return nil
+			}
+
+			for _, err := range errs {
+				fmt.Println(err)
+			}
+
+			return fmt.Errorf("%d problems found", len(errs))
+		},
+	}
+
+	verify = &cobra.Command{
+		Use:   "verify",
+		Short: "Reports applied migrations whose files were edited",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dirpath := getDir()
+			if dirpath == "" {
+				return errors.New("no migrations directory: use --dir, set MIGRA_DIR or dir in migra.yml")
+			}
+
+			migrations, err := migra.LoadDir(dirpath)
+			if err != nil {
+				return err
+			}
+
+			m, err := getMigra()
+			if err != nil {
+				return err
+			}
+
+			mismatches, err := m.Verify(cmd.Context(), migrations)
+			if err != nil {
+				return err
+			}
+
+			if len(mismatches) == 0 {
+				fmt.Println("all applied migrations match their files")
+				return nil
+			}
+
+			for _, mm := range mismatches {
+				fmt.Printf("%s\n  - recorded %s\n  + computed %s\n", mm.Name, shortChecksum(mm.Recorded), shortChecksum(mm.Computed))
+			}
+
+			return fmt.Errorf("%d applied migrations were edited", len(mismatches))
+		},
+	}
+
+	shell = &cobra.Command{
+		Use:   "shell",
+		Short: "Opens psql or mysql using the configured connection",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dsn, err := getConnectionString()
+			if err != nil {
+				return err
+			}
+
+			c, err := shellCommand(cmd.Context(), getDriver(), dsn)
+			if err != nil {
+				return err
+			}
+
+			c.Stdin = os.Stdin
+			c.Stdout = os.Stdout
+			c.Stderr = os.Stderr
+			return c.Run()
+		},
+	}
+
 	migration = migra.Migration{}
 )
 
+const (
+	// maxDescriptionLength is the number of characters of a description printed by list
+	maxDescriptionLength = 40
+
+	colorReset  = "\x1b[0m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorRed    = "\x1b[31m"
+)
+
 func main() {
-	root.AddCommand(initialize, list, push, pop)
-	root.Execute()
+	root.AddCommand(initialize, list, push, pop, current, check, plan, script, dump, doctor, verify, shell)
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
 
 func init() {
 	root.PersistentFlags().StringVar(&driver, "driver", "", "database driver to use. If unset the environment variable for MIGRA_DRIVER is used otherwise the default driver is pgx.")
-	root.PersistentFlags().StringVar(&connectionString, "conn", "", "database connection string. If unset, defaults to environment variable MIGRA_CONNECTION_STRING")
+	root.PersistentFlags().StringVar(&connectionString, "conn", "", "database connection string. If unset, defaults to the contents of --conn-file, then environment variable MIGRA_CONNECTION_STRING and then the file named by MIGRA_CONNECTION_STRING_FILE")
+	root.PersistentFlags().StringVar(&connectionFile, "conn-file", "", "file containing the database connection string, such as a mounted secret")
 	root.PersistentFlags().StringVarP(&tableName, "table", "t", migra.DefaultMigrationTable, "migrations table to use")
 	root.PersistentFlags().StringVarP(&schemaName, "schema", "s", migra.DefaultSchemaName, "schema to use")
 
+	current.Flags().BoolVar(&currentStrict, "strict", false, "exit with an error when no migrations are applied")
+
 	pop.Flags().StringVar(&popUntil, "until", "", "pop until migration with this name is reached")
 	pop.Flags().BoolVarP(&popAll, "all", "a", false, "pop all migrations")
-
-	push.Flags().StringVarP(&pushDir, "dir", "d", "", "directory containing migration files")
+	pop.Flags().BoolVar(&popForce, "force", false, "pop migrations even if they are marked irreversible")
+	pop.Flags().BoolVar(&popDryRun, "dry-run", false, "print the down sql that would be executed without popping")
+	pop.Flags().BoolVarP(&popYes, "yes", "y", false, "confirm popping migrations in production")
+	pop.Flags().StringVar(&pushEnv, "env", "", "environment to pop migrations in. If unset, defaults to environment variable MIGRA_ENV or env in migra.yml")
+
+	push.Flags().StringVarP(&dir, "dir", "d", "", fmt.Sprintf("directory containing migration files with extensions %s. If unset and no inline migration is given, defaults to environment variable MIGRA_DIR or dir in migra.yml", strings.Join(migra.SupportedFormats(), ", ")))
+	list.Flags().StringVarP(&dir, "dir", "d", "", "directory containing migration files to compare with. If unset, defaults to environment variable MIGRA_DIR or dir in migra.yml")
+	list.Flags().BoolVar(&listNoColor, "no-color", false, "disable colored output")
+	list.Flags().StringVar(&listSince, "since", "", "only list migrations applied at or after this date or RFC3339 time")
+	list.Flags().StringVar(&listUntil, "until", "", "only list migrations applied before this date or RFC3339 time")
+	verify.Flags().StringVarP(&dir, "dir", "d", "", "directory containing migration files. If unset, defaults to environment variable MIGRA_DIR or dir in migra.yml")
+	check.Flags().StringVarP(&dir, "dir", "d", "", "directory containing migration files to check. If unset, defaults to environment variable MIGRA_DIR or dir in migra.yml")
+	script.Flags().StringVarP(&dir, "dir", "d", "", "directory containing migration files. If unset, defaults to environment variable MIGRA_DIR or dir in migra.yml")
+	plan.Flags().StringVarP(&dir, "dir", "d", "", "directory containing migration files. If unset, defaults to environment variable MIGRA_DIR or dir in migra.yml")
+	plan.Flags().StringVarP(&planOutput, "output", "o", "text", "output format, text or json")
+	plan.Flags().StringVar(&pushEnv, "env", "", "environment to plan migrations for. If unset, defaults to environment variable MIGRA_ENV or env in migra.yml")
+	script.Flags().BoolVar(&sqlDown, "down", false, "print the down sql of the pending migrations in reverse order instead")
+
+	push.Flags().StringVar(&pushTag, "tag", "", "only push migrations from the directory with this tag")
+	push.Flags().StringSliceVar(&pushOnly, "only", nil, "only push the migrations from the directory with these comma separated names")
+	push.Flags().StringVar(&pushEnv, "env", "", "environment to push migrations for. If unset, defaults to environment variable MIGRA_ENV or env in migra.yml")
 	push.Flags().StringVar(&migration.Name, "name", "", "name of migration")
 	push.Flags().StringVar(&migration.Description, "desc", "", "description of migration")
 	push.Flags().StringVar(&migration.Up, "up", "", "up migration sql")
@@ -161,25 +545,167 @@ func init() {
 }
 
 func getMigra() (*migra.Migra, error) {
-	db, err := sql.Open(getDriver(), getConnectionString())
+	dsn, err := getConnectionString()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migra.Open(getDriver(), dsn)
 
 	if err != nil {
 		return nil, err
 	}
 
-	m := migra.New(db).
-		SetMigrationTable(tableName).
-		SetSchema(schemaName)
+	m.SetMigrationTable(tableName).
+		SetSchema(schemaName).
+		SetLogger(log.New(os.Stderr, "", 0))
 
 	return m, nil
 }
 
-func getConnectionString() string {
+// planPop prints the migrations which would be popped with their down sql in the order they would be reverted
+func planPop(ctx context.Context, m *migra.Migra) error {
+	migrations, err := m.PlanPop(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(migrations) == 0 {
+		return migra.ErrNoMigration
+	}
+
+	if !popAll {
+		n := 1
+		if popUntil != "" {
+			n = 0
+			for n < len(migrations) && migrations[n].Name != popUntil {
+				n++
+			}
+		}
+
+		migrations = migrations[:n]
+	}
+
+	for i := range migrations {
+		fmt.Printf("--- %s ---\n", migrations[i].Name)
+		fmt.Printf("%s\n\n", strings.Trim(migrations[i].Down, " \t"))
+	}
+
+	fmt.Printf("would pop %d migrations\n", len(migrations))
+	return nil
+}
+
+// getDir resolves the migrations directory from the --dir flag, the MIGRA_DIR environment variable or the dir key of a migra config file in the working directory.
+// An inline migration takes precedence over the environment and config file.
+func getDir() string {
+	if dir != "" {
+		return dir
+	}
+
+	if migration.Up != "" {
+		return ""
+	}
+
+	if env := os.Getenv("MIGRA_DIR"); env != "" {
+		return env
+	}
+
+	return getConfig("dir")
+}
+
+// getConfig returns the value of key in a migra config file in the working directory, empty when there is no config file
+func getConfig(key string) string {
+	v := viper.New()
+	v.SetConfigName("migra")
+	v.AddConfigPath(".")
+	if err := v.ReadInConfig(); err != nil {
+		return ""
+	}
+
+	return v.GetString(key)
+}
+
+// getConnectionString resolves the connection string from the --conn flag, the --conn-file flag,
+// the MIGRA_CONNECTION_STRING environment variable and the MIGRA_CONNECTION_STRING_FILE environment variable, in that order
+func getConnectionString() (string, error) {
 	if connectionString != "" {
-		return connectionString
+		return connectionString, nil
+	}
+
+	if connectionFile != "" {
+		return readConnectionFile(connectionFile)
+	}
+
+	if env := os.Getenv("MIGRA_CONNECTION_STRING"); env != "" {
+		return env, nil
+	}
+
+	if file := os.Getenv("MIGRA_CONNECTION_STRING_FILE"); file != "" {
+		return readConnectionFile(file)
+	}
+
+	return "", nil
+}
+
+// readConnectionFile reads a connection string from a file, ignoring trailing newlines
+func readConnectionFile(filename string) (string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("reading connection string: %w", err)
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// getEnvironment resolves the environment from the --env flag, the MIGRA_ENV environment variable or the env key of a migra config file in the working directory
+func getEnvironment() string {
+	if pushEnv != "" {
+		return pushEnv
+	}
+
+	if env := os.Getenv("MIGRA_ENV"); env != "" {
+		return env
 	}
 
-	return os.Getenv("MIGRA_CONNECTION_STRING")
+	return getConfig("env")
+}
+
+// errPopNotConfirmed is returned when popping in production was not confirmed
+var errPopNotConfirmed = errors.New("refusing to pop migrations in production without confirmation: pass --yes")
+
+// confirmPop guards popping migrations in production, which requires --yes or answering yes to a prompt when in is a terminal
+func confirmPop(in io.Reader, out io.Writer, tty bool) error {
+	if popYes || !isProduction(getEnvironment()) {
+		return nil
+	}
+
+	if !tty {
+		return errPopNotConfirmed
+	}
+
+	fmt.Fprint(out, "pop migrations in production? [y/N] ")
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return errPopNotConfirmed
+	}
+}
+
+// isProduction reports whether env names a production environment
+func isProduction(env string) bool {
+	switch strings.ToLower(env) {
+	case "prod", "production":
+		return true
+	default:
+		return false
+	}
 }
 
 func getDriver() string {
@@ -195,3 +721,180 @@ func getDriver() string {
 
 	return "pgx"
 }
+
+// shellCommand returns the command line client for the driver connected using the connection string.
+// The password is passed through the environment instead of the command line so it is not visible in the process list.
+func shellCommand(ctx context.Context, driver, connectionString string) (*exec.Cmd, error) {
+	switch driver {
+	case "pgx", "pgx/v5", "postgres":
+		config, err := pgconn.ParseConfig(connectionString)
+		if err != nil {
+			return nil, err
+		}
+
+		// psql expands a connection string given as the database, which keeps parameters such as sslmode and sslrootcert
+		c := exec.CommandContext(ctx, "psql", "-d", withoutPassword(connectionString))
+		c.Env = append(os.Environ(), "PGPASSWORD="+config.Password)
+		return c, nil
+	case "mysql":
+		config, err := mysql.ParseDSN(connectionString)
+		if err != nil {
+			return nil, err
+		}
+
+		args := []string{"-u", config.User}
+		if config.Net == "unix" {
+			args = append(args, "-S", config.Addr)
+		} else if host, port, err := net.SplitHostPort(config.Addr); err == nil {
+			args = append(args, "-h", host, "-P", port)
+		}
+
+		c := exec.CommandContext(ctx, "mysql", append(args, config.DBName)...)
+		c.Env = append(os.Environ(), "MYSQL_PWD="+config.Passwd)
+		return c, nil
+	default:
+		return nil, fmt.Errorf("no shell available for driver %q", driver)
+	}
+}
+
+// withoutPassword removes the password from a postgres connection string in url or keyword/value form
+func withoutPassword(connectionString string) string {
+	if strings.HasPrefix(connectionString, "postgres://") || strings.HasPrefix(connectionString, "postgresql://") {
+		u, err := url.Parse(connectionString)
+		if err != nil {
+			return connectionString
+		}
+
+		if u.User != nil {
+			u.User = url.User(u.User.Username())
+		}
+
+		q := u.Query()
+		q.Del("password")
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	var (
+		b    strings.Builder
+		rest = strings.TrimSpace(connectionString)
+	)
+
+	for rest != "" {
+		key, value, _ := strings.Cut(rest, "=")
+		key = strings.TrimSpace(key)
+		value = strings.TrimLeft(value, " \t\n")
+
+		// values are either single quoted, with quotes and backslashes escaped by a backslash, or end at whitespace
+		end := strings.IndexAny(value, " \t\n")
+		if strings.HasPrefix(value, "'") {
+			end = len(value)
+			for i := 1; i < len(value); i++ {
+				if value[i] == '\\' {
+					i++
+				} else if value[i] == '\'' {
+					end = i + 1
+					break
+				}
+			}
+		}
+
+		if end == -1 {
+			end = len(value)
+		}
+
+		if key != "password" {
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+
+			b.WriteString(key + "=" + value[:end])
+		}
+
+		rest = strings.TrimSpace(value[end:])
+	}
+
+	return b.String()
+}
+
+// printStatus prints the applied and missing migrations in order of position followed by the pending migrations,
+// marked with ✓ when applied, • when pending and ⚠ when missing from the source
+func printStatus(out io.Writer, report *migra.StatusReport, color bool) {
+	marks := map[migra.MigrationState]struct{ glyph, color string }{
+		migra.StateApplied: {"✓", colorGreen},
+		migra.StatePending: {"•", colorYellow},
+		migra.StateMissing: {"⚠", colorRed},
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, " \tNAME\tSTATUS\tMIGRATED AT\tDURATION\tDESCRIPTION")
+	for _, r := range report.Rows() {
+		glyph := marks[r.State].glyph
+		if color {
+			glyph = marks[r.State].color + glyph + colorReset
+		}
+
+		// pending migrations from the source have no recorded status
+		status := string(r.Status)
+		if status == "" {
+			status = r.State.String()
+		}
+
+		var migratedAt, duration string
+		if !r.MigratedAt.IsZero() {
+			migratedAt = r.MigratedAt.Local().Format(time.DateTime)
+			duration = r.Duration.String()
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", glyph, r.Name, status, migratedAt, duration, truncate(r.Description, maxDescriptionLength))
+	}
+
+	w.Flush()
+}
+
+// parseTime parses a date such as 2024-01-31 in local time or an RFC3339 time, an empty value is the zero time
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// truncate shortens s to at most n characters, replacing line breaks with spaces
+func truncate(s string, n int) string {
+	r := []rune(strings.Join(strings.Fields(s), " "))
+	if len(r) <= n {
+		return string(r)
+	}
+
+	return string(r[:n-1]) + "…"
+}
+
+// shortChecksum returns the first 12 characters of a checksum, which may be short or empty for migrations recorded before checksums were stored
+func shortChecksum(checksum string) string {
+	if checksum == "" {
+		return "(none)"
+	}
+
+	return checksum[:min(len(checksum), 12)]
+}
+
+// useColor reports whether output should be colored, which requires stdout to be a terminal
+func useColor() bool {
+	if listNoColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	return isTerminal(os.Stdout)
+}
+
+// isTerminal reports whether f is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
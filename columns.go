@@ -0,0 +1,161 @@
+package migra
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ColumnMap names the columns of the migration table, which allows migra to adopt a migration table created by another tool.
+// Empty fields use the default column names, see DefaultColumns.
+type ColumnMap struct {
+	ID            string
+	Name          string
+	Description   string
+	Up            string
+	Down          string
+	Position      string
+	MigratedAt    string
+	Checksum      string
+	Duration      string
+	Irreversible  string
+	Skipped       string
+	Key           string
+	NoTransaction string
+	Meta          string
+	SessionSQL    string
+	Status        string
+}
+
+// DefaultColumns returns the column names used when no columns are set
+func DefaultColumns() ColumnMap {
+	return ColumnMap{
+		ID:            "id",
+		Name:          "name",
+		Description:   "description",
+		Up:            "up",
+		Down:          "down",
+		Position:      "position",
+		MigratedAt:    "migrated_at",
+		Checksum:      "checksum",
+		Duration:      "duration_ms",
+		Irreversible:  "irreversible",
+		Skipped:       "skipped",
+		Key:           "key",
+		NoTransaction: "no_transaction",
+		Meta:          "meta",
+		SessionSQL:    "session_sql",
+		Status:        "status",
+	}
+}
+
+// SetColumns sets the column names of the migration table used in every query, including the id and position columns
+// which order the migrations, for example version instead of position. CreateMigrationTable creates missing columns
+// under these names and verifies that the columns exist.
+func (m *Migra) SetColumns(columns ColumnMap) *Migra {
+	def := DefaultColumns()
+	for _, c := range []struct{ col, def *string }{
+		{&columns.ID, &def.ID},
+		{&columns.Name, &def.Name},
+		{&columns.Description, &def.Description},
+		{&columns.Up, &def.Up},
+		{&columns.Down, &def.Down},
+		{&columns.Position, &def.Position},
+		{&columns.MigratedAt, &def.MigratedAt},
+		{&columns.Checksum, &def.Checksum},
+		{&columns.Duration, &def.Duration},
+		{&columns.Irreversible, &def.Irreversible},
+		{&columns.Skipped, &def.Skipped},
+		{&columns.Key, &def.Key},
+		{&columns.NoTransaction, &def.NoTransaction},
+		{&columns.Meta, &def.Meta},
+		{&columns.SessionSQL, &def.SessionSQL},
+		{&columns.Status, &def.Status},
+	} {
+		if *c.col == "" {
+			*c.col = *c.def
+		}
+	}
+
+	m.columns = &columns
+	return m
+}
+
+// Columns returns the column names of the migration table, see SetColumns
+func (m *Migra) Columns() ColumnMap {
+	if m.columns == nil {
+		return DefaultColumns()
+	}
+
+	return *m.columns
+}
+
+// names returns the column names in the order of the migration table
+func (c ColumnMap) names() []string {
+	return []string{c.ID, c.Name, c.Description, c.Up, c.Down, c.Position, c.MigratedAt, c.Checksum, c.Duration, c.Irreversible, c.Skipped, c.Key, c.NoTransaction, c.Meta, c.SessionSQL, c.Status}
+}
+
+// query replaces the {table} and {column} placeholders of stmt with the migration table and its column names,
+// where the placeholders of the columns are their default names, such as {migrated_at}.
+// The {columns} placeholder is replaced with the columns selected when scanning a migration.
+func (m *Migra) query(stmt string) string {
+	var (
+		defaults = DefaultColumns().names()
+		pairs    = []string{"{table}", m.MigrationTable()}
+	)
+
+	for i, name := range m.Columns().names() {
+		pairs = append(pairs, "{"+defaults[i]+"}", name)
+	}
+
+	stmt = strings.ReplaceAll(stmt, "{columns}", migrationColumns)
+	return strings.NewReplacer(pairs...).Replace(stmt)
+}
+
+// checkColumns returns an error listing the columns of the column map which do not exist in the migration table,
+// followed by the default name of the column they are mapped from
+func (m *Migra) checkColumns(ctx context.Context) error {
+	rows, err := m.execer().QueryContext(ctx, "SELECT column_name FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2", m.schemaName, m.tableName)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	found := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+
+		found[name] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var (
+		missing  []string
+		defaults = DefaultColumns().names()
+	)
+
+	for i, name := range m.Columns().names() {
+		if found[name] {
+			continue
+		}
+
+		if name != defaults[i] {
+			name = fmt.Sprintf("%s (%s)", name, defaults[i])
+		}
+
+		missing = append(missing, name)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("migration table %s is missing columns: %s", m.MigrationTable(), strings.Join(missing, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package migra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// PushConcurrent pushes migrations which do not depend on each other in parallel, each in its own transaction,
+// while every migration is only pushed after the migrations listed in its DependsOn were pushed.
+// At most maxParallel migrations are pushed at once, limited further by the maximum number of open connections of the database.
+// A failed migration does not stop independent migrations, but the migrations depending on it are not pushed.
+// All errors are returned joined together.
+func (m *Migra) PushConcurrent(ctx context.Context, migrations []Migration, maxParallel int) error {
+	sorted, err := topoSort(migrations)
+	if err != nil {
+		return err
+	}
+
+	// a transaction can only be used by one migration at a time
+	if m.tx != nil {
+		return m.PushMany(ctx, sorted)
+	}
+
+	// the distributed lock is held once for all migrations, instead of being taken and released by every parallel push
+	return m.withDistributedLock(ctx, func(ctx context.Context) error {
+		unlocked := *m
+		unlocked.lockOwner = ""
+		return unlocked.pushConcurrent(ctx, sorted, maxParallel)
+	})
+}
+
+// pushConcurrent pushes the sorted migrations in parallel, see PushConcurrent
+func (m *Migra) pushConcurrent(ctx context.Context, sorted []Migration, maxParallel int) error {
+	if open := m.db.Stats().MaxOpenConnections; open > 0 && maxParallel > open {
+		maxParallel = open
+	}
+
+	maxParallel = max(maxParallel, 1)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   []error
+		sem    = make(chan struct{}, maxParallel)
+		done   = make(map[string]chan struct{}, len(sorted))
+		failed = make(map[string]bool)
+	)
+
+	for i := range sorted {
+		done[sorted[i].Name] = make(chan struct{})
+	}
+
+	for i := range sorted {
+		mig := sorted[i]
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[mig.Name])
+
+			fail := func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				failed[mig.Name] = true
+				errs = append(errs, err)
+			}
+
+			for _, dep := range mig.DependsOn {
+				<-done[dep]
+
+				mu.Lock()
+				depFailed := failed[dep]
+				mu.Unlock()
+
+				if depFailed {
+					fail(fmt.Errorf("migration %s not pushed: dependency %s failed", mig.Name, dep))
+					return
+				}
+			}
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := m.Push(ctx, &mig); err != nil {
+				fail(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+package migra
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// connector returns a connector opening dsn with d
+func connector(d driver.Driver, dsn string) driver.Connector {
+	if dc, ok := d.(driver.DriverContext); ok {
+		if c, err := dc.OpenConnector(dsn); err == nil {
+			return c
+		}
+	}
+
+	return dsnConnector{driver: d, dsn: dsn}
+}
+
+// dsnConnector opens connections for drivers which do not implement driver.DriverContext
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// funcConnector resolves the connection string for every connection, see OpenFunc
+type funcConnector struct {
+	driver driver.Driver
+	dsn    func() (string, error)
+}
+
+func (c funcConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn, err := c.dsn()
+	if err != nil {
+		return nil, err
+	}
+
+	return connector(c.driver, dsn).Connect(ctx)
+}
+
+func (c funcConnector) Driver() driver.Driver {
+	return c.driver
+}
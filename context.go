@@ -0,0 +1,55 @@
+package migra
+
+import "context"
+
+// SetContext sets the default context used by the methods without a context parameter, such as PushNow.
+// It is meant for scripts which have no context of their own, the methods taking a context are not affected.
+// By default context.Background is used.
+func (m *Migra) SetContext(ctx context.Context) *Migra {
+	m.ctx = ctx
+	return m
+}
+
+// defaultContext returns the default context, see SetContext
+func (m *Migra) defaultContext() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+
+	return m.ctx
+}
+
+// CreateMigrationTableNow calls CreateMigrationTable with the default context, see SetContext
+func (m *Migra) CreateMigrationTableNow() error {
+	return m.CreateMigrationTable(m.defaultContext())
+}
+
+// PushNow calls Push with the default context, see SetContext
+func (m *Migra) PushNow(migration *Migration) error {
+	return m.Push(m.defaultContext(), migration)
+}
+
+// PushManyNow calls PushMany with the default context, see SetContext
+func (m *Migra) PushManyNow(migrations []Migration) error {
+	return m.PushMany(m.defaultContext(), migrations)
+}
+
+// ApplyNow calls Apply with the default context, see SetContext
+func (m *Migra) ApplyNow(src Source) error {
+	return m.Apply(m.defaultContext(), src)
+}
+
+// PopNow calls Pop with the default context, see SetContext
+func (m *Migra) PopNow() error {
+	return m.Pop(m.defaultContext())
+}
+
+// PopAllNow calls PopAll with the default context, see SetContext
+func (m *Migra) PopAllNow() (int, error) {
+	return m.PopAll(m.defaultContext())
+}
+
+// ListNow calls List with the default context, see SetContext
+func (m *Migra) ListNow() ([]Migration, error) {
+	return m.List(m.defaultContext())
+}
@@ -0,0 +1,66 @@
+package migra
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ErrNonTransactionalDDL is returned in strict mode when a migration executed in a transaction contains ddl
+// on a database which commits ddl implicitly, see SetStrictDDL
+var ErrNonTransactionalDDL = errors.New("migration contains ddl which is not transactional")
+
+// nonTransactionalDDL are the drivers of databases which commit ddl implicitly, so a failed migration does not roll it back
+var nonTransactionalDDL = map[string]bool{
+	"mysql": true,
+}
+
+// ddlPattern matches statements which start with a ddl keyword
+var ddlPattern = regexp.MustCompile(`(?i)^(CREATE|ALTER|DROP|TRUNCATE|RENAME)\b`)
+
+// SetStrictDDL makes pushes fail with ErrNonTransactionalDDL instead of logging a warning when a migration executed in a transaction
+// contains ddl on a database which commits ddl implicitly, such as MySQL. Migrations marked with NoTransaction are not checked.
+func (m *Migra) SetStrictDDL(strict bool) *Migra {
+	m.strictDDL = strict
+	return m
+}
+
+// driverPackage returns the package name of a driver, which names the driver of databases which were not opened by name
+func driverPackage(driver any) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", driver), "*")
+	pkg, _, _ := strings.Cut(name, ".")
+	return pkg
+}
+
+// checkDDL warns, or fails in strict mode, when the sql contains ddl and the database does not roll back ddl with the transaction.
+// Statements are detected heuristically by their leading keyword.
+func (m *Migra) checkDDL(name, sql string) error {
+	if !nonTransactionalDDL[m.driver] {
+		return nil
+	}
+
+	stmts := newStatementReader(strings.NewReader(sql))
+	for {
+		stmt, err := stmts.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !ddlPattern.MatchString(stmt) {
+			continue
+		}
+
+		if m.strictDDL {
+			return fmt.Errorf("%w: migration %s on %s", ErrNonTransactionalDDL, name, m.driver)
+		}
+
+		m.logf("migration %s contains ddl which %s commits implicitly, it is not rolled back if the migration fails", name, m.driver)
+		return nil
+	}
+}
@@ -0,0 +1,47 @@
+package migra
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func TestCheckDDL(t *testing.T) {
+	db, err := sql.Open("mysql", "user@/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	var (
+		buf bytes.Buffer
+		m   = New(db).SetLogger(log.New(&buf, "", 0))
+	)
+
+	if err := m.checkDDL("users", "INSERT INTO seen VALUES (1); CREATE TABLE users (id INT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "migration users contains ddl") {
+		t.Fatalf("expected warning for create table got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := m.checkDDL("seed", "INSERT INTO users VALUES (1)"); err != nil || buf.Len() != 0 {
+		t.Fatalf("expected no warning without ddl got %v %q", err, buf.String())
+	}
+
+	if err := m.SetStrictDDL(true).checkDDL("users", "create table users (id INT)"); !errors.Is(err, ErrNonTransactionalDDL) {
+		t.Fatalf("expected ErrNonTransactionalDDL got %v", err)
+	}
+
+	if err := New(nil).SetStrictDDL(true).checkDDL("users", "CREATE TABLE users (id INT)"); err != nil {
+		t.Fatalf("expected ddl to be allowed on postgres got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package migra
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDependencyCycle is returned when migrations depend on each other in a cycle
+var ErrDependencyCycle = errors.New("migration dependency cycle")
+
+// topoSort orders migrations so that every migration comes after the migrations it depends on.
+// Migrations which do not depend on each other keep their original order.
+func topoSort(migrations []Migration) ([]Migration, error) {
+	index := make(map[string]int, len(migrations))
+	for i := range migrations {
+		index[migrations[i].Name] = i
+	}
+
+	var (
+		pending    = make([]int, len(migrations))
+		dependents = make([][]int, len(migrations))
+	)
+
+	for i := range migrations {
+		for _, dep := range migrations[i].DependsOn {
+			j, ok := index[dep]
+			if !ok {
+				return nil, fmt.Errorf("migration %s depends on unknown migration %s", migrations[i].Name, dep)
+			}
+
+			pending[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	var (
+		sorted = make([]Migration, 0, len(migrations))
+		done   = make([]bool, len(migrations))
+	)
+
+	for len(sorted) < len(migrations) {
+		next := -1
+		for i := range migrations {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+
+		if next == -1 {
+			return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(findCycle(migrations, index, done), " -> "))
+		}
+
+		done[next] = true
+		sorted = append(sorted, migrations[next])
+		for _, i := range dependents[next] {
+			pending[i]--
+		}
+	}
+
+	return sorted, nil
+}
+
+// findCycle returns the names of a dependency cycle among the migrations which are not done
+func findCycle(migrations []Migration, index map[string]int, done []bool) []string {
+	var (
+		visiting = make(map[int]int)
+		path     []int
+		cycle    []string
+		visit    func(i int) bool
+	)
+
+	visit = func(i int) bool {
+		if start, ok := visiting[i]; ok {
+			for _, j := range path[start:] {
+				cycle = append(cycle, migrations[j].Name)
+			}
+
+			cycle = append(cycle, migrations[i].Name)
+			return true
+		}
+
+		visiting[i] = len(path)
+		path = append(path, i)
+
+		for _, dep := range migrations[i].DependsOn {
+			if j := index[dep]; !done[j] && visit(j) {
+				return true
+			}
+		}
+
+		path = path[:len(path)-1]
+		delete(visiting, i)
+		return false
+	}
+
+	for i := range migrations {
+		if !done[i] && visit(i) {
+			break
+		}
+	}
+
+	return cycle
+}
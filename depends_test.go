@@ -0,0 +1,73 @@
+package migra
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func names(migrations []Migration) string {
+	var n []string
+	for i := range migrations {
+		n = append(n, migrations[i].Name)
+	}
+
+	return strings.Join(n, ",")
+}
+
+func TestTopoSort(t *testing.T) {
+	migrations := []Migration{
+		{Name: "posts", DependsOn: []string{"users"}},
+		{Name: "seed"},
+		{Name: "users"},
+		{Name: "comments", DependsOn: []string{"posts", "users"}},
+	}
+
+	sorted, err := topoSort(migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := names(sorted); got != "seed,users,posts,comments" {
+		t.Fatalf("unexpected order %s", got)
+	}
+}
+
+func TestTopoSortKeepsOrder(t *testing.T) {
+	migrations := []Migration{{Name: "c"}, {Name: "a"}, {Name: "b"}}
+
+	sorted, err := topoSort(migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := names(sorted); got != "c,a,b" {
+		t.Fatalf("unexpected order %s", got)
+	}
+}
+
+func TestTopoSortCycle(t *testing.T) {
+	migrations := []Migration{
+		{Name: "a", DependsOn: []string{"c"}},
+		{Name: "b", DependsOn: []string{"a"}},
+		{Name: "c", DependsOn: []string{"b"}},
+		{Name: "d"},
+	}
+
+	_, err := topoSort(migrations)
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("expected ErrDependencyCycle got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "a -> c -> b -> a") {
+		t.Fatalf("expected error to name the cycle, got %q", err.Error())
+	}
+}
+
+func TestTopoSortUnknown(t *testing.T) {
+	migrations := []Migration{{Name: "a", DependsOn: []string{"missing"}}}
+
+	if _, err := topoSort(migrations); err == nil {
+		t.Fatal("expected unknown dependency to fail")
+	}
+}
@@ -0,0 +1,136 @@
+package migra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLockHeld is returned by pushes when the lock table is held by another owner, see SetDistributedLock
+var ErrLockHeld = errors.New("migration lock is held by another owner")
+
+// LockTable returns the fully qualified name of the table holding the lock of AcquireLock
+func (m *Migra) LockTable() string {
+	return m.MigrationTable() + "_lock"
+}
+
+// SetDistributedLock makes pushes such as Push, PushMany, PushDir, PushFS and Apply acquire the lock table as owner before pushing
+// and release it afterwards, returning ErrLockHeld when another owner holds it. The lock is renewed every half ttl while pushing,
+// so ttl only bounds how long the lock outlives a job which died without releasing it. When the lock can not be renewed
+// the push is cancelled. An empty owner disables the lock, which is the default.
+func (m *Migra) SetDistributedLock(owner string, ttl time.Duration) *Migra {
+	m.lockOwner = owner
+	m.lockTTL = ttl
+	return m
+}
+
+// withDistributedLock runs fn while holding the distributed lock, if set, see SetDistributedLock.
+// The context passed to fn is cancelled when the lock could not be renewed.
+func (m *Migra) withDistributedLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.lockOwner == "" {
+		return fn(ctx)
+	}
+
+	if m.lockTTL <= 0 {
+		return errors.New("distributed lock ttl must be positive")
+	}
+
+	ok, err := m.AcquireLock(ctx, m.lockOwner, m.lockTTL)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrLockHeld
+	}
+
+	defer m.ReleaseLock(context.WithoutCancel(ctx), m.lockOwner)
+
+	ctx, abort := context.WithCancel(ctx)
+	defer abort()
+
+	var (
+		lost    error
+		done    = make(chan struct{})
+		stopped = make(chan struct{})
+	)
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(m.lockTTL / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ok, err := m.AcquireLock(ctx, m.lockOwner, m.lockTTL)
+				if err == nil && !ok {
+					err = ErrLockHeld
+				}
+
+				if err != nil {
+					lost = fmt.Errorf("renewing migration lock: %w", err)
+					abort()
+					return
+				}
+			}
+		}
+	}()
+
+	err = fn(ctx)
+	close(done)
+	<-stopped
+
+	if lost != nil {
+		return errors.Join(lost, err)
+	}
+
+	return err
+}
+
+// AcquireLock acquires a lock stored in the lock table for owner until ttl elapses, and reports whether it was acquired.
+// Unlike advisory locks, which are released with the connection, the lock coordinates migrations across ephemeral jobs.
+// It is acquired when no lock is held, when the lock expired or when owner already holds it, which extends the lock by ttl.
+// The lock table is created when it does not exist. Within InTx the lock is still taken outside of the bound transaction,
+// since it would not be visible to other jobs before the transaction commits.
+func (m *Migra) AcquireLock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	stmt := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+		owner TEXT NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	)`, m.LockTable())
+
+	if _, err := m.wrap(m.db).ExecContext(ctx, stmt); err != nil && !isConcurrentCreate(err) {
+		return false, err
+	}
+
+	stmt = fmt.Sprintf(`INSERT INTO %[1]s AS l (owner, expires_at) VALUES ($1, clock_timestamp() + $2 * INTERVAL '1 millisecond')
+		ON CONFLICT (id) DO UPDATE SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+		WHERE l.owner = EXCLUDED.owner OR l.expires_at <= clock_timestamp()
+		RETURNING owner`, m.LockTable())
+
+	var holder string
+	err := m.wrap(m.db).QueryRowContext(ctx, stmt, owner, ttl.Milliseconds()).Scan(&holder)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseLock releases the lock of the lock table when it is held by owner
+func (m *Migra) ReleaseLock(ctx context.Context, owner string) error {
+	_, err := m.wrap(m.db).ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE owner = $1", m.LockTable()), owner)
+	return err
+}
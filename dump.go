@@ -0,0 +1,170 @@
+package migra
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// userRelations restricts a query joining pg_class c and pg_namespace n to the relations of user schemas,
+// excluding migra's own tables which are given as $1 to $4 by ownTables
+const userRelations = `n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname NOT LIKE 'pg_toast%'
+		AND NOT (n.nspname = $1 AND c.relname IN ($2, $3, $4))`
+
+// ownTables returns the schema followed by the names of the migration, schema and lock tables
+func (m *Migra) ownTables() []any {
+	return []any{m.schemaName, m.tableName, m.tableName + "_schema", m.tableName + "_lock"}
+}
+
+// SetSchemaDumper sets the function used by DumpSchema to write the schema of the database,
+// which allows dumping databases other than postgres. By default the postgres catalog is queried.
+func (m *Migra) SetSchemaDumper(fn func(ctx context.Context, db *sql.DB, w io.Writer) error) *Migra {
+	m.schemaDumper = fn
+	return m
+}
+
+// DumpSchema writes the DDL of the tables, constraints and indexes of the database to w, excluding migra's own tables.
+// The output is ordered deterministically so snapshots can be compared to verify the schema produced by a chain of migrations.
+// It is meant as a readable snapshot rather than a replacement for pg_dump, sequences, views and functions are not included.
+func (m *Migra) DumpSchema(ctx context.Context, w io.Writer) error {
+	if m.schemaDumper != nil {
+		return m.schemaDumper(ctx, m.db, w)
+	}
+
+	return m.dumpPostgres(ctx, w)
+}
+
+// dumpPostgres writes the tables followed by their constraints and indexes from the postgres catalog
+func (m *Migra) dumpPostgres(ctx context.Context, w io.Writer) error {
+	args := m.ownTables()
+
+	rows, err := m.wrap(m.db).QueryContext(ctx, `SELECT quote_ident(n.nspname) || '.' || quote_ident(c.relname), quote_ident(a.attname),
+		format_type(a.atttypid, a.atttypmod), a.attnotnull, COALESCE(pg_get_expr(d.adbin, d.adrelid), '')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		LEFT JOIN pg_attrdef d ON d.adrelid = c.oid AND d.adnum = a.attnum
+		WHERE c.relkind IN ('r', 'p') AND `+userRelations+`
+		ORDER BY n.nspname, c.relname, a.attnum`, args...)
+
+	if err != nil {
+		return err
+	}
+
+	var (
+		table   string
+		columns []string
+	)
+
+	flush := func() error {
+		if table == "" {
+			return nil
+		}
+
+		_, err := fmt.Fprintf(w, "CREATE TABLE %s (\n\t%s\n);\n\n", table, strings.Join(columns, ",\n\t"))
+		return err
+	}
+
+	for rows.Next() {
+		var (
+			t, column, dataType, def string
+			notNull                  bool
+		)
+
+		if err := rows.Scan(&t, &column, &dataType, &notNull, &def); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if t != table {
+			if err := flush(); err != nil {
+				rows.Close()
+				return err
+			}
+
+			table, columns = t, nil
+		}
+
+		col := column + " " + dataType
+		if notNull {
+			col += " NOT NULL"
+		}
+
+		if def != "" {
+			col += " DEFAULT " + def
+		}
+
+		columns = append(columns, col)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	// foreign keys come last so the tables they reference have their keys
+	constraints := `SELECT format('ALTER TABLE %s.%s ADD CONSTRAINT %s %s;', quote_ident(n.nspname), quote_ident(c.relname), quote_ident(con.conname), pg_get_constraintdef(con.oid))
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE con.contype IN ('p', 'u', 'c', 'x', 'f') AND ` + userRelations + `
+		ORDER BY con.contype = 'f', n.nspname, c.relname, con.conname`
+
+	// indexes backing constraints are created by the constraints
+	indexes := `SELECT pg_get_indexdef(i.indexrelid) || ';'
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_class ic ON ic.oid = i.indexrelid
+		WHERE NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = i.indexrelid) AND ` + userRelations + `
+		ORDER BY n.nspname, c.relname, ic.relname`
+
+	for _, stmt := range []string{constraints, indexes} {
+		if err := m.dumpLines(ctx, w, stmt, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dumpLines writes the single text column of each row returned by stmt as a line
+func (m *Migra) dumpLines(ctx context.Context, w io.Writer, stmt string, args ...any) error {
+	rows, err := m.wrap(m.db).QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	var n int
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+
+		n++
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if n > 0 {
+		_, err = fmt.Fprintln(w)
+	}
+
+	return err
+}
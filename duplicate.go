@@ -0,0 +1,101 @@
+package migra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrDuplicateObject is returned when the up sql of a migration creates an object which already exists
+// and the duplicate object policy is DuplicateError, see SetOnDuplicateObject
+var ErrDuplicateObject = errors.New("migration creates an object which already exists")
+
+// DuplicatePolicy determines what happens when the up sql of a migration creates an object which already exists,
+// such as a table created manually before adopting migra
+type DuplicatePolicy int
+
+const (
+	// DuplicateFail fails the migration with the error of the database
+	DuplicateFail DuplicatePolicy = iota
+
+	// DuplicateSkip rolls back the up sql and records the migration as applied and skipped
+	DuplicateSkip
+
+	// DuplicateError fails the migration with ErrDuplicateObject
+	DuplicateError
+)
+
+// duplicateCodes are the sqlstates of creating objects which already exist
+var duplicateCodes = map[string]bool{
+	"42P04": true, // duplicate_database
+	"42P06": true, // duplicate_schema
+	"42P07": true, // duplicate_table
+	"42701": true, // duplicate_column
+	"42710": true, // duplicate_object
+	"42723": true, // duplicate_function
+}
+
+// SetOnDuplicateObject sets the policy for migrations whose up sql creates an object which already exists.
+// The default is DuplicateFail. Migrations marked with NoTransaction always fail.
+func (m *Migra) SetOnDuplicateObject(policy DuplicatePolicy) *Migra {
+	m.onDuplicate = policy
+	return m
+}
+
+// execUpDuplicate executes the up sql of a migration applying the duplicate object policy,
+// and reports whether the up sql was executed rather than skipped
+func (m *Migra) execUpDuplicate(ctx context.Context, tx Execer, name, up string) (bool, error) {
+	switch m.onDuplicate {
+	case DuplicateSkip:
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT migra_duplicate"); err != nil {
+			return false, err
+		}
+
+		ran := true
+		if err := m.execUp(ctx, tx, name, up); err != nil {
+			if !isDuplicate(err) {
+				return false, err
+			}
+
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT migra_duplicate"); err != nil {
+				return false, err
+			}
+
+			m.logf("skipping migration %s: %v", name, err)
+			ran = false
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT migra_duplicate"); err != nil {
+			return false, err
+		}
+
+		return ran, nil
+	case DuplicateError:
+		if err := m.execUp(ctx, tx, name, up); err != nil {
+			if isDuplicate(err) {
+				return false, fmt.Errorf("%w: %w", ErrDuplicateObject, err)
+			}
+
+			return false, err
+		}
+
+		return true, nil
+	default:
+		return true, m.execUp(ctx, tx, name, up)
+	}
+}
+
+// isConcurrentCreate reports whether err was caused by creating an object which was created concurrently,
+// which IF NOT EXISTS does not guard against and which may surface as a unique violation of the system catalogs
+func isConcurrentCreate(err error) bool {
+	var pgErr *pgconn.PgError
+	return isDuplicate(err) || errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isDuplicate reports whether err was caused by creating an object which already exists
+func isDuplicate(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && duplicateCodes[pgErr.Code]
+}
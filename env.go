@@ -0,0 +1,23 @@
+package migra
+
+// SetEnvironment sets the active environment. Pushed migrations which list Environments that do not include
+// the active environment are skipped and logged, see SetLogger.
+func (m *Migra) SetEnvironment(env string) *Migra {
+	m.environment = env
+	return m
+}
+
+// InEnvironment reports whether the migration runs in env, which is the case for every env when Environments is empty
+func (m *Migration) InEnvironment(env string) bool {
+	if len(m.Environments) == 0 {
+		return true
+	}
+
+	for _, e := range m.Environments {
+		if e == env {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,117 @@
+package migra
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event is sent on the channel returned by ApplyWithEvents.
+// It is one of EventStarted, EventApplied, EventSkipped, EventFailed or EventDone.
+type Event interface {
+	event()
+}
+
+// EventStarted is sent before any migration is pushed
+type EventStarted struct{}
+
+// EventApplied is sent after a migration was pushed, with the time its up sql took to execute
+type EventApplied struct {
+	Name     string
+	Duration time.Duration
+}
+
+// EventSkipped is sent for a migration which did not need to be pushed, such as one that was pushed before
+type EventSkipped struct {
+	Name string
+}
+
+// EventFailed is sent when pushing a migration failed
+type EventFailed struct {
+	Name string
+	Err  error
+}
+
+// EventDone is the last event sent before the channel is closed, with the error returned by pushing the migrations
+type EventDone struct {
+	Err error
+}
+
+func (EventStarted) event() {}
+func (EventApplied) event() {}
+func (EventSkipped) event() {}
+func (EventFailed) event()  {}
+func (EventDone) event()    {}
+
+// ApplyWithEvents pushes the migrations as a batch in a goroutine, see PushMany, sending an event as each migration is pushed.
+// Skipped events are sent once all migrations were pushed successfully. The channel is buffered to hold every event,
+// so the goroutine never blocks on a reader that stopped receiving, and it is closed after EventDone was sent.
+// Cancelling ctx cancels the push, which is reported by EventDone. Migrations which fail validation return an error without pushing.
+func (m *Migra) ApplyWithEvents(ctx context.Context, src []Migration) (<-chan Event, error) {
+	for i := range src {
+		if err := m.check(&src[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	events := make(chan Event, len(src)+2)
+	o := &eventObserver{
+		next:     m.observe(),
+		events:   events,
+		reported: make(map[string]bool, len(src)),
+	}
+
+	withEvents := *m
+	withEvents.observer = o
+
+	go func() {
+		defer close(events)
+
+		events <- EventStarted{}
+		err := withEvents.PushMany(ctx, src)
+
+		if err == nil {
+			for i := range src {
+				o.send(m.normalizeName(src[i].Name), EventSkipped{Name: m.normalizeName(src[i].Name)})
+			}
+		}
+
+		events <- EventDone{Err: err}
+	}()
+
+	return events, nil
+}
+
+// eventObserver sends at most one event per migration, which keeps the events within the channel's buffer
+type eventObserver struct {
+	next     Observer
+	events   chan<- Event
+	mu       sync.Mutex
+	reported map[string]bool
+}
+
+func (o *eventObserver) send(name string, e Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.reported[name] {
+		return
+	}
+
+	o.reported[name] = true
+	o.events <- e
+}
+
+func (o *eventObserver) MigrationApplied(name string, dur time.Duration) {
+	o.next.MigrationApplied(name, dur)
+	o.send(name, EventApplied{Name: name, Duration: dur})
+}
+
+func (o *eventObserver) MigrationFailed(name string, err error) {
+	o.next.MigrationFailed(name, err)
+	o.send(name, EventFailed{Name: name, Err: err})
+}
+
+func (o *eventObserver) MigrationReverted(name string) {
+	o.next.MigrationReverted(name)
+}
@@ -0,0 +1,81 @@
+package migra_test
+
+import (
+	"testing"
+
+	"github.com/cristosal/migra"
+)
+
+func TestApplyWithEvents(t *testing.T) {
+	m := getMigra(t)
+
+	if err := m.Push(ctx, &migra.Migration{Name: "First", Up: "SELECT 1", Down: "SELECT 1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := m.ApplyWithEvents(ctx, []migra.Migration{
+		{Name: "First", Up: "SELECT 1", Down: "SELECT 1"},
+		{Name: "Second", Up: "SELECT 2", Down: "SELECT 2"},
+		{Name: "Third", Up: "SELEC 3", Down: "SELECT 3"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []migra.Event
+	for e := range events {
+		got = append(got, e)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 events got %v", got)
+	}
+
+	if _, ok := got[0].(migra.EventStarted); !ok {
+		t.Fatalf("expected started event got %v", got[0])
+	}
+
+	if failed, ok := got[1].(migra.EventFailed); !ok || failed.Name != "Third" || failed.Err == nil {
+		t.Fatalf("expected failed event for Third got %v", got[1])
+	}
+
+	if done, ok := got[2].(migra.EventDone); !ok || done.Err == nil {
+		t.Fatalf("expected done event with error got %v", got[2])
+	}
+
+	events, err = m.ApplyWithEvents(ctx, []migra.Migration{
+		{Name: "First", Up: "SELECT 1", Down: "SELECT 1"},
+		{Name: "Second", Up: "SELECT 2", Down: "SELECT 2"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got = nil
+	for e := range events {
+		got = append(got, e)
+	}
+
+	expected := []migra.Event{
+		migra.EventStarted{},
+		nil, // applied event, duration varies
+		migra.EventSkipped{Name: "First"},
+		migra.EventDone{},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d events got %v", len(expected), got)
+	}
+
+	if applied, ok := got[1].(migra.EventApplied); !ok || applied.Name != "Second" {
+		t.Fatalf("expected applied event for Second got %v", got[1])
+	}
+
+	for i, e := range expected {
+		if e != nil && got[i] != e {
+			t.Fatalf("expected event %d to be %v got %v", i, e, got[i])
+		}
+	}
+}
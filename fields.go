@@ -0,0 +1,113 @@
+package migra
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// checkFields returns an error naming the field when a value of a decoded migration file does not have the type
+// of the Migration field with the same mapstructure tag, naming fields with prefix. Keys without a field are ignored.
+func checkFields(prefix string, settings map[string]any) error {
+	return checkStruct(prefix, reflect.TypeOf(Migration{}), settings)
+}
+
+// checkStruct checks the values of settings against the fields of the struct type t, naming fields with prefix
+func checkStruct(prefix string, t reflect.Type, settings map[string]any) error {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("mapstructure"); tag != "" {
+			fields[tag] = t.Field(i).Type
+		}
+	}
+
+	// sorted so the first problem reported is deterministic
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		ft, ok := fields[key]
+		if !ok {
+			continue
+		}
+
+		if err := checkValue(prefix+key, ft, settings[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkValue checks that v can be decoded into a field of type t
+func checkValue(field string, t reflect.Type, v any) error {
+	if v == nil {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			return fieldError(field, "a string", v)
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			return fieldError(field, "a boolean", v)
+		}
+	case reflect.Int, reflect.Int64:
+		switch n := v.(type) {
+		case int, int64:
+		case float64:
+			if n != float64(int64(n)) {
+				return fieldError(field, "an integer", v)
+			}
+		default:
+			return fieldError(field, "an integer", v)
+		}
+	case reflect.Slice:
+		// a string is split on commas
+		if _, ok := v.(string); ok {
+			return nil
+		}
+
+		items, ok := v.([]any)
+		if !ok {
+			return fieldError(field, "a list", v)
+		}
+
+		for i, item := range items {
+			if err := checkValue(fmt.Sprintf("%s[%d]", field, i), t.Elem(), item); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		values, ok := v.(map[string]any)
+		if !ok {
+			return fieldError(field, "a map", v)
+		}
+
+		for key, value := range values {
+			if err := checkValue(field+"."+key, t.Elem(), value); err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer:
+		values, ok := v.(map[string]any)
+		if !ok {
+			return fieldError(field, "a map", v)
+		}
+
+		return checkStruct(field+".", t.Elem(), values)
+	}
+
+	return nil
+}
+
+// fieldError describes a value of a migration file with the wrong type
+func fieldError(field, expected string, v any) error {
+	return fmt.Errorf("field %s must be %s, got %v", field, expected, v)
+}
@@ -0,0 +1,44 @@
+package migra
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// SetAllowMigrationTableAccess allows migrations to reference the migration table in their up and down sql.
+// By default such migrations are rejected, since changing the migration table breaks the bookkeeping done by migra.
+func (m *Migra) SetAllowMigrationTableAccess(allow bool) *Migra {
+	m.allowTableAccess = allow
+	return m
+}
+
+// tableGuard returns the pattern matching references to the migration table, compiled whenever the table is set
+func tableGuard(table string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`(?i)(^|[^\w$])"?%s"?($|[^\w$])`, regexp.QuoteMeta(table)))
+}
+
+// checkAccess checks the up, down and batch sql of a migration which is about to be executed, see checkTableAccess.
+// Migrations which were already pushed are not checked, so they keep working when they reference a table which is later used as the migration table.
+func (m *Migra) checkAccess(name string, migration *Migration) error {
+	sql := []string{migration.Up, migration.Down}
+	if migration.Batch != nil {
+		sql = append(sql, migration.Batch.SQL)
+	}
+
+	for _, s := range sql {
+		if err := m.checkTableAccess(name, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkTableAccess returns ErrMigrationTableAccess if the sql references the migration table and access is not allowed
+func (m *Migra) checkTableAccess(name, sql string) error {
+	if m.allowTableAccess || !m.guard.MatchString(sql) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: migration %s references %s", ErrMigrationTableAccess, name, m.MigrationTable())
+}
@@ -0,0 +1,19 @@
+package migra
+
+// IdentityStyle determines how the id and position columns of the migration table are generated
+type IdentityStyle int
+
+const (
+	// IdentitySerial generates the id and position columns using SERIAL, which is supported by every version of postgres
+	IdentitySerial IdentityStyle = iota
+
+	// IdentityGenerated generates the id and position columns using identity columns, which are preferred since postgres 10
+	IdentityGenerated
+)
+
+// SetIdentityStyle sets how the id and position columns are generated when the migration table is created by CreateMigrationTable.
+// The default is IdentitySerial. Changing the style does not alter existing migration tables.
+func (m *Migra) SetIdentityStyle(style IdentityStyle) *Migra {
+	m.identityStyle = style
+	return m
+}
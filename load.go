@@ -0,0 +1,236 @@
+package migra
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// loadFile reads a migration from a file
+func loadFile(filepath string, hook mapstructure.DecodeHookFunc) (*Migration, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+	return parseFile(filepath, f, osReader(filepath), hook)
+}
+
+// parseFile parses a single migration from r using the parser registered for the extension of name, see SetDecodeHook for hook
+func parseFile(name string, r io.Reader, read sqlFileReader, hook mapstructure.DecodeHookFunc) (*Migration, error) {
+	p, err := parserFor(name, hook)
+	if err != nil {
+		return nil, err
+	}
+
+	migration, err := p.Parse(name, r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if err := migration.readSQLFiles(read); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return migration, nil
+}
+
+// LoadFileMany reads the migrations defined in a file. The file either defines a single migration
+// or a list of migrations under the top level migrations key, for example in yaml:
+//
+//	migrations:
+//	  - name: first
+//	    up: CREATE TABLE first (id SERIAL PRIMARY KEY)
+//	  - name: second
+//	    up: CREATE TABLE second (id SERIAL PRIMARY KEY)
+//
+// Instead of inline sql, up_file and down_file may reference files containing the sql relative to the migration file.
+// The migrations are ordered by their dependencies, which must be defined in the same file, see LoadDir for dependencies across files.
+func LoadFileMany(filepath string) ([]Migration, error) {
+	migrations, err := loadFileMany(filepath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return topoSort(migrations)
+}
+
+// loadFileMany reads the migrations defined in a file in the order they are defined, see LoadFileMany
+func loadFileMany(filepath string, hook mapstructure.DecodeHookFunc) ([]Migration, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+	return parseFileMany(filepath, f, osReader(filepath), hook)
+}
+
+// parseFileMany parses the migrations defined in r using the parser registered for the extension of name
+func parseFileMany(name string, r io.Reader, read sqlFileReader, hook mapstructure.DecodeHookFunc) ([]Migration, error) {
+	migrations, err := parseMany(name, r, hook)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	for i := range migrations {
+		if err := migrations[i].readSQLFiles(read); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return migrations, nil
+}
+
+// sqlFileReader reads a sql file referenced by a migration file
+type sqlFileReader func(name string) ([]byte, error)
+
+// osReader reads sql files relative to the directory of the migration file
+func osReader(filepath string) sqlFileReader {
+	return func(name string) ([]byte, error) {
+		if !path.IsAbs(name) {
+			name = path.Join(path.Dir(filepath), name)
+		}
+
+		return os.ReadFile(name)
+	}
+}
+
+// fsReader reads sql files within the filesystem relative to the directory of the migration file
+func fsReader(filesystem fs.FS, filepath string) sqlFileReader {
+	return func(name string) ([]byte, error) {
+		return fs.ReadFile(filesystem, path.Join(path.Dir(filepath), name))
+	}
+}
+
+// readSQLFiles sets the up and down sql from the files referenced by UpFile and DownFile
+func (m *Migration) readSQLFiles(read sqlFileReader) error {
+	if m.UpFile != "" {
+		if m.Up != "" {
+			return fmt.Errorf("migration %s: up and up_file can not both be set", m.Name)
+		}
+
+		b, err := read(m.UpFile)
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+
+		m.Up = string(b)
+	}
+
+	if m.DownFile != "" {
+		if m.Down != "" {
+			return fmt.Errorf("migration %s: down and down_file can not both be set", m.Name)
+		}
+
+		b, err := read(m.DownFile)
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+
+		m.Down = string(b)
+	}
+
+	return nil
+}
+
+// loadFileFS reads a migration from a file within the filesystem
+func loadFileFS(filesystem fs.FS, filepath string, hook mapstructure.DecodeHookFunc) (*Migration, error) {
+	f, err := filesystem.Open(path.Join(".", filepath))
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+	return parseFile(filepath, f, fsReader(filesystem, filepath), hook)
+}
+
+// loadFileManyFS reads the migrations defined in a file within the filesystem, see LoadFileMany
+func loadFileManyFS(filesystem fs.FS, filepath string, hook mapstructure.DecodeHookFunc) ([]Migration, error) {
+	f, err := filesystem.Open(path.Join(".", filepath))
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+	return parseFileMany(filepath, f, fsReader(filesystem, filepath), hook)
+}
+
+// LoadDir reads all migration files inside a directory without pushing them.
+// Files may define a single migration or a list of migrations, see LoadFileMany.
+// Migrations are ordered by file name, unless they depend on migrations which come later.
+func LoadDir(dirpath string) ([]Migration, error) {
+	return loadDir(dirpath, nil)
+}
+
+// loadDir reads all migration files inside a directory like LoadDir, decoding them with hook, see SetDecodeHook
+func loadDir(dirpath string, hook mapstructure.DecodeHookFunc) ([]Migration, error) {
+	entries, err := os.ReadDir(dirpath)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for i := range entries {
+		if entries[i].IsDir() || !isSupported(entries[i].Name()) {
+			continue
+		}
+
+		found, err := loadFileMany(path.Join(dirpath, entries[i].Name()), hook)
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, found...)
+	}
+
+	return topoSort(migrations)
+}
+
+// loadDirFS reads all migration files inside a directory of the filesystem, recursing into subdirectories.
+// Like LoadDir, migrations are ordered by file name unless they depend on migrations which come later.
+func loadDirFS(filesystem fs.FS, dirpath string, hook mapstructure.DecodeHookFunc) ([]Migration, error) {
+	migrations, err := readDirFS(filesystem, dirpath, hook)
+	if err != nil {
+		return nil, err
+	}
+
+	return topoSort(migrations)
+}
+
+// readDirFS reads all migration files inside a directory of the filesystem in file name order, recursing into subdirectories
+func readDirFS(filesystem fs.FS, dirpath string, hook mapstructure.DecodeHookFunc) ([]Migration, error) {
+	entries, err := fs.ReadDir(filesystem, dirpath)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		filename := path.Join(dirpath, entry.Name())
+
+		var (
+			found []Migration
+			err   error
+		)
+
+		if entry.IsDir() {
+			found, err = readDirFS(filesystem, filename, hook)
+		} else if isSupported(filename) {
+			found, err = loadFileManyFS(filesystem, filename, hook)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, found...)
+	}
+
+	return migrations, nil
+}
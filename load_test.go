@@ -0,0 +1,203 @@
+package migra_test
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cristosal/migra"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	filepath := path.Join(dir, name)
+	if err := os.WriteFile(filepath, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	return filepath
+}
+
+func TestLoadFileManySingle(t *testing.T) {
+	filepath := writeFile(t, t.TempDir(), "single.yml", `
+name: "single"
+up: "CREATE TABLE single(id serial primary key)"
+down: "DROP TABLE single"`)
+
+	migrations, err := migra.LoadFileMany(filepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 1 || migrations[0].Name != "single" {
+		t.Fatalf("expected single migration, got %v", migrations)
+	}
+}
+
+func TestLoadFileManyList(t *testing.T) {
+	filepath := writeFile(t, t.TempDir(), "list.yml", `
+migrations:
+  - name: "first"
+    up: "CREATE TABLE first(id serial primary key)"
+    down: "DROP TABLE first"
+  - name: "second"
+    up: "CREATE TABLE second(id serial primary key)"
+    down: "DROP TABLE second"`)
+
+	migrations, err := migra.LoadFileMany(filepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 2 || migrations[0].Name != "first" || migrations[1].Name != "second" {
+		t.Fatalf("expected first and second migrations in order, got %v", migrations)
+	}
+
+	if migrations[1].Down != "DROP TABLE second" {
+		t.Fatalf("unexpected down sql %q", migrations[1].Down)
+	}
+}
+
+func TestSupportedFormats(t *testing.T) {
+	formats := strings.Join(migra.SupportedFormats(), ",")
+
+	for _, ext := range []string{"yml", "yaml", "json", "toml"} {
+		if !strings.Contains(formats, ext) {
+			t.Fatalf("expected %s to be supported, got %s", ext, formats)
+		}
+	}
+
+	for _, ext := range []string{"env", "ini", "properties", "hcl"} {
+		if strings.Contains(formats, ext) {
+			t.Fatalf("expected %s not to be supported, got %s", ext, formats)
+		}
+	}
+}
+
+func TestLoadDirSkipsUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "1.yml", `
+name: "first"
+up: "SELECT 1"`)
+	writeFile(t, dir, "README.md", "# migrations")
+	writeFile(t, dir, ".env", "DATABASE_URL=postgres://localhost")
+
+	migrations, err := migra.LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 1 || migrations[0].Name != "first" {
+		t.Fatalf("expected only the yml migration, got %v", migrations)
+	}
+}
+
+func TestLoadFileManySQLFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "up.sql", "CREATE TABLE files (id SERIAL PRIMARY KEY)")
+	writeFile(t, dir, "down.sql", "DROP TABLE files")
+	filepath := writeFile(t, dir, "files.yml", `
+name: files
+up_file: up.sql
+down_file: down.sql`)
+
+	migrations, err := migra.LoadFileMany(filepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if migrations[0].Up != "CREATE TABLE files (id SERIAL PRIMARY KEY)" || migrations[0].Down != "DROP TABLE files" {
+		t.Fatalf("expected sql from files, got %+v", migrations[0])
+	}
+}
+
+func TestLoadFileManySQLFileConflict(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "up.sql", "CREATE TABLE files (id SERIAL PRIMARY KEY)")
+	filepath := writeFile(t, dir, "files.yml", `
+name: files
+up: CREATE TABLE files (id SERIAL PRIMARY KEY)
+up_file: up.sql`)
+
+	if _, err := migra.LoadFileMany(filepath); err == nil {
+		t.Fatal("expected error when both up and up_file are set")
+	}
+}
+
+func TestFSSourceSQLFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/files.yml":    {Data: []byte("name: files\nup_file: sql/up.sql\ndown_file: sql/down.sql")},
+		"migrations/sql/up.sql":   {Data: []byte("CREATE TABLE files (id SERIAL PRIMARY KEY)")},
+		"migrations/sql/down.sql": {Data: []byte("DROP TABLE files")},
+	}
+
+	migrations, err := migra.FSSource{FS: fsys, Dir: "migrations"}.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 1 {
+		t.Fatalf("expected 1 migration got %d", len(migrations))
+	}
+
+	if migrations[0].Up != "CREATE TABLE files (id SERIAL PRIMARY KEY)" || migrations[0].Down != "DROP TABLE files" {
+		t.Fatalf("expected sql from files, got %+v", migrations[0])
+	}
+}
+
+func TestLoadDirDependsAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "1_posts.yml", `
+name: posts
+up: CREATE TABLE posts (id SERIAL PRIMARY KEY, user_id INT REFERENCES users)
+depends_on: [users]`)
+	writeFile(t, dir, "2_users.yml", `
+name: users
+up: CREATE TABLE users (id SERIAL PRIMARY KEY)`)
+
+	migrations, err := migra.LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 2 || migrations[0].Name != "users" || migrations[1].Name != "posts" {
+		t.Fatalf("expected users before posts, got %v", migrations)
+	}
+
+	if errs := migra.CheckDir(dir); len(errs) != 0 {
+		t.Fatalf("expected no errors got %v", errs)
+	}
+
+	fsys := fstest.MapFS{
+		"migrations/1_posts.yml":   {Data: []byte("name: posts\nup: SELECT 1\ndepends_on: [users]")},
+		"migrations/sub/users.yml": {Data: []byte("name: users\nup: SELECT 1")},
+	}
+
+	migrations, err = migra.FSSource{FS: fsys, Dir: "migrations"}.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 2 || migrations[0].Name != "users" || migrations[1].Name != "posts" {
+		t.Fatalf("expected users before posts, got %v", migrations)
+	}
+}
+
+func TestLoadMetaKeysLowerCase(t *testing.T) {
+	filepath := writeFile(t, t.TempDir(), "meta.yml", `
+name: meta
+up: SELECT 1
+meta:
+  Ticket: OPS-1`)
+
+	migrations, err := migra.LoadFileMany(filepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if migrations[0].Meta["ticket"] != "OPS-1" {
+		t.Fatalf("expected meta key to be lower case got %v", migrations[0].Meta)
+	}
+}
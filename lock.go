@@ -0,0 +1,155 @@
+package migra
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrLockMismatch is returned when migrations diverge from the entries of a lock file, see CheckLock
+var ErrLockMismatch = errors.New("migrations do not match lock")
+
+// LockEntry is the expected name and checksum of a migration listed in a lock file
+type LockEntry struct {
+	Name     string
+	Checksum string
+}
+
+// WriteLock writes a lock file listing the names and checksums of the migrations in order.
+// Each line of the file contains the checksum of a migration followed by a space and its name, written verbatim,
+// so names containing line breaks are rejected.
+func WriteLock(path string, migrations []Migration) error {
+	var b strings.Builder
+	for i := range migrations {
+		if strings.ContainsAny(migrations[i].Name, "\r\n") {
+			return fmt.Errorf("migration %q: names containing line breaks can not be locked", migrations[i].Name)
+		}
+
+		fmt.Fprintf(&b, "%s %s\n", migrations[i].ComputeChecksum(), migrations[i].Name)
+	}
+
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// LoadLock reads the entries of a lock file written by WriteLock. Empty lines are ignored,
+// while spaces are kept since they may be part of names.
+func LoadLock(path string) ([]LockEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var (
+		entries []LockEntry
+		scanner = bufio.NewScanner(f)
+	)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		checksum, name, ok := strings.Cut(line, " ")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s:%d: expected checksum and name", path, n)
+		}
+
+		entries = append(entries, LockEntry{Name: name, Checksum: checksum})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// CheckLock reports how migrations diverge from the entries of a lock, which is the case when migrations
+// are missing or extra, are in a different order, or have a different checksum. Every divergence is
+// returned joined together and wraps ErrLockMismatch.
+func CheckLock(lock []LockEntry, migrations []Migration) error {
+	var (
+		errs     []error
+		locked   = make(map[string]LockEntry, len(lock))
+		inSource = make(map[string]*Migration, len(migrations))
+	)
+
+	for _, entry := range lock {
+		locked[entry.Name] = entry
+	}
+
+	for i := range migrations {
+		inSource[migrations[i].Name] = &migrations[i]
+	}
+
+	var lockOrder, sourceOrder []string
+	for _, entry := range lock {
+		mig, ok := inSource[entry.Name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: migration %s is missing", ErrLockMismatch, entry.Name))
+			continue
+		}
+
+		lockOrder = append(lockOrder, entry.Name)
+		if checksum := mig.ComputeChecksum(); checksum != entry.Checksum {
+			errs = append(errs, fmt.Errorf("%w: migration %s has checksum %s, expected %s", ErrLockMismatch, entry.Name, checksum, entry.Checksum))
+		}
+	}
+
+	for i := range migrations {
+		if _, ok := locked[migrations[i].Name]; !ok {
+			errs = append(errs, fmt.Errorf("%w: migration %s is not locked", ErrLockMismatch, migrations[i].Name))
+			continue
+		}
+
+		sourceOrder = append(sourceOrder, migrations[i].Name)
+	}
+
+	for i := range lockOrder {
+		if i < len(sourceOrder) && lockOrder[i] != sourceOrder[i] {
+			errs = append(errs, fmt.Errorf("%w: migration %s is out of order, expected %s", ErrLockMismatch, sourceOrder[i], lockOrder[i]))
+			break
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkLocked reports the migrations which are not listed in the lock or have a different checksum,
+// which unlike CheckLock allows pushing a subset of the locked migrations
+func checkLocked(lock []LockEntry, migrations []Migration) error {
+	locked := make(map[string]string, len(lock))
+	for _, entry := range lock {
+		locked[entry.Name] = entry.Checksum
+	}
+
+	var errs []error
+	for i := range migrations {
+		expected, ok := locked[migrations[i].Name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: migration %s is not locked", ErrLockMismatch, migrations[i].Name))
+			continue
+		}
+
+		if checksum := migrations[i].ComputeChecksum(); checksum != expected {
+			errs = append(errs, fmt.Errorf("%w: migration %s has checksum %s, expected %s", ErrLockMismatch, migrations[i].Name, checksum, expected))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SetLockFile makes pushes check migrations against the entries of a lock file before pushing anything, see LoadLock.
+// Apply, PushDir and PushFS check the loaded migrations with CheckLock, while Push and PushMany, which may push some of the locked
+// migrations, check that every migration is locked with the same checksum. An empty lock, such as from an empty lock file,
+// allows no migrations to be pushed. PushReader is not checked since its checksum is only known once it was executed.
+func (m *Migra) SetLockFile(lock []LockEntry) *Migra {
+	m.lock = lock
+	m.lockSet = true
+	return m
+}
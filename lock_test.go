@@ -0,0 +1,128 @@
+package migra_test
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/cristosal/migra"
+)
+
+var lockedMigrations = []migra.Migration{
+	{Name: "Create Users", Up: "CREATE TABLE users (id SERIAL PRIMARY KEY)", Down: "DROP TABLE users"},
+	{Name: "Create Posts", Up: "CREATE TABLE posts (id SERIAL PRIMARY KEY)", Down: "DROP TABLE posts"},
+	{Name: "Create Tags", Up: "CREATE TABLE tags (id SERIAL PRIMARY KEY)", Down: "DROP TABLE tags"},
+}
+
+func writeLock(t *testing.T) []migra.LockEntry {
+	filepath := path.Join(t.TempDir(), "migrations.lock")
+	if err := migra.WriteLock(filepath, lockedMigrations); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := migra.LoadLock(filepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return lock
+}
+
+func TestLockRoundTrip(t *testing.T) {
+	lock := writeLock(t)
+
+	if len(lock) != 3 || lock[0].Name != "Create Users" || lock[0].Checksum != lockedMigrations[0].ComputeChecksum() {
+		t.Fatalf("unexpected lock entries %v", lock)
+	}
+
+	if err := migra.CheckLock(lock, lockedMigrations); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockNames(t *testing.T) {
+	var (
+		filepath   = path.Join(t.TempDir(), "migrations.lock")
+		migrations = []migra.Migration{{Name: " Padded Name ", Up: "SELECT 1"}}
+	)
+
+	if err := migra.WriteLock(filepath, migrations); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := migra.LoadLock(filepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migra.CheckLock(lock, migrations); err != nil {
+		t.Fatalf("expected spaces in names to be kept got %v", err)
+	}
+
+	if err := migra.WriteLock(filepath, []migra.Migration{{Name: "Two\nLines", Up: "SELECT 1"}}); err == nil {
+		t.Fatal("expected name containing a line break to be rejected")
+	}
+}
+
+func TestCheckLock(t *testing.T) {
+	lock := writeLock(t)
+	users, posts, tags := lockedMigrations[0], lockedMigrations[1], lockedMigrations[2]
+	edited := posts
+	edited.Up = "CREATE TABLE posts (id BIGSERIAL PRIMARY KEY)"
+
+	tests := []struct {
+		name       string
+		migrations []migra.Migration
+		want       string
+	}{
+		{"missing", []migra.Migration{users, tags}, "Create Posts is missing"},
+		{"extra", []migra.Migration{users, posts, tags, {Name: "Create Likes", Up: "SELECT 1"}}, "Create Likes is not locked"},
+		{"reordered", []migra.Migration{users, tags, posts}, "out of order"},
+		{"checksum", []migra.Migration{users, edited, tags}, "Create Posts has checksum"},
+	}
+
+	for _, tt := range tests {
+		err := migra.CheckLock(lock, tt.migrations)
+		if !errors.Is(err, migra.ErrLockMismatch) {
+			t.Fatalf("%s: expected ErrLockMismatch got %v", tt.name, err)
+		}
+
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: expected %q in %q", tt.name, tt.want, err.Error())
+		}
+	}
+}
+
+func TestApplyLock(t *testing.T) {
+	m := migra.New(nil).SetLockFile(writeLock(t))
+
+	// the lock is checked before the database is used
+	err := m.Apply(context.Background(), migra.SliceSource(lockedMigrations[:2]))
+	if !errors.Is(err, migra.ErrLockMismatch) {
+		t.Fatalf("expected ErrLockMismatch got %v", err)
+	}
+
+	edited := lockedMigrations[0]
+	edited.Up = "SELECT 2"
+
+	if err := m.Push(context.Background(), &edited); !errors.Is(err, migra.ErrLockMismatch) {
+		t.Fatalf("expected Push to check the lock got %v", err)
+	}
+
+	if err := m.PushMany(context.Background(), []migra.Migration{edited}); !errors.Is(err, migra.ErrLockMismatch) {
+		t.Fatalf("expected PushMany to check the lock got %v", err)
+	}
+
+	// an empty lock file allows no migrations
+	empty, err := migra.LoadLock(writeFile(t, t.TempDir(), "empty.lock", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.SetLockFile(empty)
+	if err := m.Push(context.Background(), &lockedMigrations[0]); !errors.Is(err, migra.ErrLockMismatch) {
+		t.Fatalf("expected empty lock to reject migrations got %v", err)
+	}
+}
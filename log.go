@@ -0,0 +1,16 @@
+package migra
+
+import "log"
+
+// SetLogger sets the logger used to report noteworthy events such as skipped migrations. Logging is disabled when l is nil.
+func (m *Migra) SetLogger(l *log.Logger) *Migra {
+	m.logger = l
+	return m
+}
+
+// logf logs to the logger if one is set
+func (m *Migra) logf(format string, args ...any) {
+	if m.logger != nil {
+		m.logger.Printf(format, args...)
+	}
+}
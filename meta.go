@@ -0,0 +1,28 @@
+package migra
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// ListByMeta returns the migrations whose metadata has the value for key, ordered by position.
+// Metadata is stored as JSONB in migration tables created by migra, a TEXT column holding json, such as of a table adopted with SetColumns, works as well.
+// Keys of metadata loaded from migration files are lower case, since the keys of migration files are case insensitive.
+func (m *Migra) ListByMeta(ctx context.Context, key, value string) ([]Migration, error) {
+	sql := m.query(`SELECT {columns} FROM {table} WHERE {meta}::jsonb ->> $1 = $2 ORDER BY {position} ASC, {id} ASC`)
+	return m.queryMigrations(ctx, sql, key, value)
+}
+
+// encodeMeta returns the metadata encoded as json, or nil to store NULL when there is none
+func encodeMeta(meta map[string]string) (any, error) {
+	if len(meta) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,48 @@
+package migra
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SetExecMiddleware sets a function wrapping every Execer migra executes sql with, including the transactions
+// of pushes and pops and the statements recording migrations. It allows tracing or logging each statement
+// without migra depending on a tracing library. A nil middleware disables wrapping.
+func (m *Migra) SetExecMiddleware(middleware func(next Execer) Execer) *Migra {
+	m.execMiddleware = middleware
+	return m
+}
+
+// wrap returns ex wrapped by the exec middleware, or ex when no middleware is set
+func (m *Migra) wrap(ex Execer) Execer {
+	if m.execMiddleware == nil {
+		return ex
+	}
+
+	return m.execMiddleware(ex)
+}
+
+// wrappedTx is a transaction whose statements are executed through the exec middleware
+type wrappedTx struct {
+	Execer
+	tx *sql.Tx
+}
+
+func (t *wrappedTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *wrappedTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// wrappedConn is a connection whose statements are executed through the exec middleware.
+// Transactions begun on it are wrapped by begin.
+type wrappedConn struct {
+	Execer
+	conn conn
+}
+
+func (c *wrappedConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return c.conn.BeginTx(ctx, opts)
+}
@@ -0,0 +1,63 @@
+package migra_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/cristosal/migra"
+)
+
+type recordingExecer struct {
+	next       migra.Execer
+	statements *[]string
+}
+
+func (e recordingExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	*e.statements = append(*e.statements, query)
+	return e.next.ExecContext(ctx, query, args...)
+}
+
+func (e recordingExecer) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	*e.statements = append(*e.statements, query)
+	return e.next.QueryContext(ctx, query, args...)
+}
+
+func (e recordingExecer) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	*e.statements = append(*e.statements, query)
+	return e.next.QueryRowContext(ctx, query, args...)
+}
+
+func TestExecMiddleware(t *testing.T) {
+	m := getMigra(t)
+
+	var statements []string
+	m.SetExecMiddleware(func(next migra.Execer) migra.Execer {
+		return recordingExecer{next: next, statements: &statements}
+	})
+
+	if err := m.Push(ctx, &migra.Migration{Name: "Traced", Up: "SELECT 'traced up'", Down: "SELECT 'traced down'"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Pop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	m.SetExecMiddleware(nil)
+
+	expected := []string{
+		"INSERT INTO " + m.MigrationTable(),
+		"SELECT 'traced up'",
+		"SELECT 'traced down'",
+		"DELETE FROM " + m.MigrationTable(),
+	}
+
+	all := strings.Join(statements, "\n")
+	for _, s := range expected {
+		if !strings.Contains(all, s) {
+			t.Fatalf("expected middleware to see %q got:\n%s", s, all)
+		}
+	}
+}
@@ -2,15 +2,20 @@ package migra
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
-	"os"
-	"path"
+	"log"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/spf13/viper"
+	"github.com/mitchellh/mapstructure"
 )
 
 const (
@@ -19,47 +24,237 @@ const (
 
 	// DefaultSchemaName is the name given to the migration table schema if not overriden by SetSchemaName
 	DefaultSchemaName = "public"
+
+	// DefaultMaxOpenConns is the maximum number of open connections for databases opened with Open.
+	// Migrations are mostly executed one after another so few connections are needed.
+	DefaultMaxOpenConns = 4
+
+	// DefaultMaxIdleConns is the maximum number of idle connections for databases opened with Open
+	DefaultMaxIdleConns = 2
+
+	// DefaultConnMaxLifetime is the maximum amount of time a connection is reused for databases opened with Open.
+	// This prevents long running services from holding on to stale connections.
+	DefaultConnMaxLifetime = 30 * time.Minute
 )
 
 var (
 	ErrNoMigration = errors.New("no migration found")
+
+	// ErrIrreversible is returned when popping a migration marked as irreversible, see SetForceIrreversible
+	ErrIrreversible = errors.New("migration is irreversible")
+
+	// ErrSQLNotStored is returned when popping a migration whose down sql was not stored, see SetStoreSQL and PopWith
+	ErrSQLNotStored = errors.New("migration sql is not stored")
+
+	// ErrUnknownDriver is returned by Open when the driver has not been registered with database/sql
+	ErrUnknownDriver = errors.New("unknown driver")
+
+	// ErrMigrationTableAccess is returned when a migration references the migration table, see SetAllowMigrationTableAccess
+	ErrMigrationTableAccess = errors.New("migration references the migration table")
+
+	// ErrMigrationConflict is returned when a migration was already pushed under the same name with different sql, see SetDetectConflicts
+	ErrMigrationConflict = errors.New("migration conflicts with pushed migration")
+
+	// ErrNoDown is returned when pushing a migration without down sql which is not marked irreversible, see SetRequireDown
+	ErrNoDown = errors.New("migration has no down sql and is not irreversible")
+
+	// ErrInitTimeout is returned by InitTimeout when the migration table could not be created in time
+	ErrInitTimeout = errors.New("timed out creating migration table")
+
+	// ErrAmbiguousMigration is returned by Apply and directory pushes when migrations were edited or renamed after being applied, see Reconcile
+	ErrAmbiguousMigration = errors.New("ambiguous migration")
+
+	// ErrApplyTimeout is returned by ApplyWithTimeout when the migrations could not be applied in time
+	ErrApplyTimeout = errors.New("timed out applying migrations")
+
+	// ErrPostCheckFailed is returned when the post check of a migration returns false after its up sql was executed
+	ErrPostCheckFailed = errors.New("migration post check failed")
 )
 
 // Migration is a structured change to the database
 type Migration struct {
-	ID          int64
-	Name        string `mapstructure:"name"`
-	Description string `mapstructure:"description"`
-	Up          string `mapstructure:"up"`
-	Down        string `mapstructure:"down"`
-	Position    int64
-	MigratedAt  time.Time
+	ID            int64
+	Name          string            `mapstructure:"name"`
+	Description   string            `mapstructure:"description"`
+	Up            string            `mapstructure:"up"`
+	Down          string            `mapstructure:"down"`
+	UpFile        string            `mapstructure:"up_file"`
+	DownFile      string            `mapstructure:"down_file"`
+	Tags          []string          `mapstructure:"tags"`
+	DependsOn     []string          `mapstructure:"depends_on"`
+	Irreversible  bool              `mapstructure:"irreversible"`
+	Environments  []string          `mapstructure:"environments"`
+	Repeatable    bool              `mapstructure:"repeatable"`
+	AllowRerun    bool              `mapstructure:"allow_rerun"`
+	NoTransaction bool              `mapstructure:"no_transaction"`
+	Precheck      string            `mapstructure:"precheck"`
+	PostCheck     string            `mapstructure:"post_check"`
+	Batch         *BatchSpec        `mapstructure:"batch"`
+	Meta          map[string]string `mapstructure:"meta"`
+	SessionSQL    []string          `mapstructure:"session_sql"`
+	Position      int64
+	MigratedAt    time.Time
+	Checksum      string
+	Duration      time.Duration
+	Skipped       bool
+	Status        MigrationStatus
+}
+
+// HasTag reports whether the migration is tagged with tag
+func (m *Migration) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Key returns a key identifying the migration which, unlike ID, is the same in every database.
+// It is the hex encoded sha256 hash of the migration's name. The key stored in the key column when pushed
+// is computed from the normalized name, see Migra.Key when names are normalized.
+func (m *Migration) Key() string {
+	return migrationKey(m.Name)
+}
+
+// Key returns the key stored for the migration when pushed, which is the key of its name after applying the name normalization,
+// see Migration.Key and SetNameNormalization
+func (m *Migra) Key(migration *Migration) string {
+	return migrationKey(m.normalizeName(migration.Name))
+}
+
+// migrationKey returns the key of a migration with the given name, see Migration.Key
+func migrationKey(name string) string {
+	h := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(h[:])
+}
+
+// ComputeChecksum returns the hex encoded sha256 checksum of the migration's up and down sql, and its batch sql if it has a batch spec
+func (m *Migration) ComputeChecksum() string {
+	h := sha256.New()
+	io.WriteString(h, m.Up)
+	h.Write([]byte{0})
+	io.WriteString(h, m.Down)
+
+	// the checksums of migrations without a batch spec stay the same as before batches were supported
+	if m.Batch != nil {
+		h.Write([]byte{0})
+		io.WriteString(h, m.Batch.SQL)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 // Migra contains methods for migrating an sql database
 type Migra struct {
-	db         *sql.DB
-	tableName  string
-	schemaName string
+	db           *sql.DB
+	tableName    string
+	schemaName   string
+	prepareCheck bool
+	preBatchSQL  string
+	postBatchSQL string
+
+	allowTableAccess  bool
+	trackSchema       bool
+	normalize         func(string) string
+	omitSQL           bool
+	forceIrreversible bool
+	observer          Observer
+	checkpointEvery   int
+	tx                *sql.Tx
+	detectConflicts   bool
+	environment       string
+	logger            *log.Logger
+	onStatementError  func(stmt string, err error) bool
+	identityStyle     IdentityStyle
+	lock              []LockEntry
+	lockSet           bool
+	requireDown       bool
+	columns           *ColumnMap
+	schemaDumper      func(ctx context.Context, db *sql.DB, w io.Writer) error
+	ctx               context.Context
+	execMiddleware    func(next Execer) Execer
+	onDuplicate       DuplicatePolicy
+	baseline          *Migration
+	decodeHook        mapstructure.DecodeHookFunc
+	guard             *regexp.Regexp
+	driver            string
+	strictDDL         bool
+	lockOwner         string
+	lockTTL           time.Duration
 }
 
-// Open is a helper function for opening the sql database and creating the migra instance
+// Open is a helper function for opening the sql database and creating the migra instance.
+// The connection pool is configured with DefaultMaxOpenConns, DefaultMaxIdleConns and DefaultConnMaxLifetime.
 func Open(driver, dsn string) (*Migra, error) {
+	if err := checkDriver(driver); err != nil {
+		return nil, err
+	}
+
 	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	return New(db), nil
+	m := New(db)
+	m.driver = driver
+	m.SetConnPool(DefaultMaxOpenConns, DefaultMaxIdleConns, DefaultConnMaxLifetime)
+	return m, nil
+}
+
+// OpenFunc is like Open but calls dsn for the connection string of every new connection, which supports short lived credentials
+// such as passwords issued by a secrets manager. dsn is called once when opening to return its error early.
+func OpenFunc(driverName string, dsn func() (string, error)) (*Migra, error) {
+	if err := checkDriver(driverName); err != nil {
+		return nil, err
+	}
+
+	if _, err := dsn(); err != nil {
+		return nil, err
+	}
+
+	// sql.Open does not connect, it is only used to look up the driver
+	lookup, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	d := lookup.Driver()
+	lookup.Close()
+
+	m := New(sql.OpenDB(funcConnector{driver: d, dsn: dsn}))
+	m.driver = driverName
+	m.SetConnPool(DefaultMaxOpenConns, DefaultMaxIdleConns, DefaultConnMaxLifetime)
+	return m, nil
+}
+
+// checkDriver returns ErrUnknownDriver listing the available drivers if driver is not registered
+func checkDriver(driver string) error {
+	drivers := sql.Drivers()
+	for _, d := range drivers {
+		if d == driver {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w %q, available drivers are: %s (is the driver imported?)", ErrUnknownDriver, driver, strings.Join(drivers, ", "))
 }
 
 // New creates a new Migra instance.
 func New(db *sql.DB) *Migra {
-	return &Migra{
+	m := &Migra{
 		db:         db,
 		tableName:  DefaultMigrationTable,
 		schemaName: DefaultSchemaName,
+		guard:      tableGuard(DefaultMigrationTable),
+	}
+
+	if db != nil {
+		m.driver = driverPackage(db.Driver())
 	}
+
+	return m
 }
 
 // MigrationTable returns the fully qualified, schema prefixed table name
@@ -67,20 +262,95 @@ func (m *Migra) MigrationTable() string {
 	return m.schemaName + "." + m.tableName
 }
 
+// SchemaName returns the schema of the migration table, see SetSchema
+func (m *Migra) SchemaName() string {
+	return m.schemaName
+}
+
+// TableName returns the name of the migration table without the schema, see SetMigrationTable
+func (m *Migra) TableName() string {
+	return m.tableName
+}
+
 // DB Allows access to the underlying sql database
 func (m *Migra) DB() *sql.DB {
 	return m.db
 }
 
+// Close closes the underlying sql database.
+// This also applies to instances created with New, where the database is owned by the caller.
+func (m *Migra) Close() error {
+	return m.db.Close()
+}
+
+// SetConnPool configures the connection pool of the underlying sql database.
+// See sql.DB SetMaxOpenConns, SetMaxIdleConns and SetConnMaxLifetime for the meaning of each value.
+func (m *Migra) SetConnPool(maxOpen, maxIdle int, maxLifetime time.Duration) *Migra {
+	m.db.SetMaxOpenConns(maxOpen)
+	m.db.SetMaxIdleConns(maxIdle)
+	m.db.SetConnMaxLifetime(maxLifetime)
+	return m
+}
+
 // SetMigrationTable sets the default table where migrations will be stored and executed
 func (m *Migra) SetMigrationTable(table string) *Migra {
 	if table != "" {
 		m.tableName = table
+		m.guard = tableGuard(table)
+	}
+
+	return m
+}
+
+// WithTable returns a copy of the instance which shares the database and settings but keeps its migrations in another table.
+// This allows independent sets of migrations, for example one per subsystem, to be tracked separately in the same database.
+func (m *Migra) WithTable(table string) *Migra {
+	c := *m
+	return c.SetMigrationTable(table)
+}
+
+// SetNameNormalization sets a function which normalizes migration names before they are stored or looked up,
+// for example strings.TrimSpace or FoldName. By default names are used as is.
+// Names already stored in the migration table are not normalized, so changing the normalization
+// of an existing table requires updating the stored names accordingly.
+func (m *Migra) SetNameNormalization(normalize func(string) string) *Migra {
+	m.normalize = normalize
+	return m
+}
+
+// FoldName normalizes a migration name by trimming surrounding whitespace and converting it to lower case,
+// making name comparisons case insensitive when used with SetNameNormalization
+func FoldName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// normalizeName applies the configured name normalization
+func (m *Migra) normalizeName(name string) string {
+	if m.normalize == nil {
+		return name
 	}
 
+	return m.normalize(name)
+}
+
+// SetStoreSQL sets whether the up and down sql of migrations is stored in the migration table, which is the default.
+// When disabled only the name, description and checksum of a migration are recorded while the sql is still executed.
+// This suits organizations which must not keep sensitive sql in the database, however migrations pushed this way
+// can only be popped with PopWith, which takes the down sql from the migration source.
+func (m *Migra) SetStoreSQL(store bool) *Migra {
+	m.omitSQL = !store
 	return m
 }
 
+// recordedSQL returns the up and down sql values to store in the migration table
+func (m *Migra) recordedSQL(up, down string) (any, any) {
+	if m.omitSQL {
+		return nil, nil
+	}
+
+	return up, down
+}
+
 // SetSchema sets the schema for the migration table
 func (m *Migra) SetSchema(schema string) *Migra {
 	if schema != "" {
@@ -92,153 +362,173 @@ func (m *Migra) SetSchema(schema string) *Migra {
 
 // CreateMigrationTable creates the table and schema where migrations will be stored and executed.
 // The name of the table can be set using the SetMigrationTable method.
+// The schema is only created when it does not exist, so roles without the privilege to create schemas can use an existing one.
+// It is safe to call concurrently, such as by several instances of a service starting at once.
 func (m *Migra) CreateMigrationTable(ctx context.Context) error {
 	if m.schemaName == "" {
 		m.schemaName = DefaultSchemaName
 	}
 
 	if m.tableName == "" {
-		m.tableName = DefaultMigrationTable
+		m.SetMigrationTable(DefaultMigrationTable)
 	}
 
-	_, err := m.db.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", m.schemaName))
+	exists, err := m.schemaExists(ctx)
 	if err != nil {
 		return err
 	}
 
-	_, err = m.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(255) NOT NULL UNIQUE,
-		description TEXT,
-		up TEXT,
-		down TEXT,
-		position SERIAL NOT NULL,
-		migrated_at TIMESTAMPTZ
-	);`, m.MigrationTable()))
-
-	return err
-}
-
-// DropMigrationTable
-func (m *Migra) DropMigrationTable(ctx context.Context) error {
-	_, err := m.db.ExecContext(ctx, fmt.Sprintf("DROP TABLE %s", m.MigrationTable()))
-	return err
-}
+	stmts := m.InitSQL()
 
-// Push adds a migration to the database and executes it
-func (m *Migra) Push(ctx context.Context, migration *Migration) error {
-	if migration.Name == "" {
-		return errors.New("migration name is required")
+	// the first statement creates the schema, which requires the create privilege on the database even when the schema exists
+	if exists {
+		stmts = stmts[1:]
 	}
 
-	if migration.Up == "" {
-		return errors.New("up sql is required")
+	for _, stmt := range stmts {
+		if err := m.execInit(ctx, stmt); err != nil {
+			return err
+		}
 	}
 
-	var (
-		sql  = fmt.Sprintf("SELECT name FROM %s WHERE name = $1", m.MigrationTable())
-		name string
-		row  = m.db.QueryRowContext(ctx, sql, migration.Name)
-	)
-
-	row.Scan(&name)
-
-	if name == migration.Name {
-		// we have already pushed it
-		return nil
+	if err := m.backfillKeys(ctx); err != nil {
+		return err
 	}
 
-	tx, err := m.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
+	// an adopted migration table may lack columns which are not added by the statements above
+	if m.columns != nil {
+		return m.checkColumns(ctx)
 	}
 
-	defer tx.Rollback()
+	return nil
+}
 
-	// insert record of the migration
-	sql = fmt.Sprintf("INSERT INTO %s (name, description, up, down) VALUES ($1, $2, $3, $4)", m.MigrationTable())
-	if _, err := tx.ExecContext(ctx, sql, migration.Name, migration.Description, migration.Up, migration.Down); err != nil {
-		return err
-	}
+// execInit executes a statement creating the migration table, ignoring errors of a concurrent initialization which created
+// the object after IF NOT EXISTS found it missing. Within InTx the statement runs in a savepoint, since the failed statement
+// would otherwise abort the bound transaction.
+func (m *Migra) execInit(ctx context.Context, stmt string) error {
+	if m.tx == nil {
+		if _, err := m.execer().ExecContext(ctx, stmt); err != nil && !isConcurrentCreate(err) {
+			return err
+		}
 
-	// execute up migration
-	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
-		return err
+		return nil
 	}
 
-	// set migration as executed
-	sql = fmt.Sprintf("UPDATE %s SET migrated_at = NOW() WHERE name = $1", m.MigrationTable())
-	if _, err := tx.ExecContext(ctx, sql, migration.Name); err != nil {
+	ex := m.execer()
+	if _, err := ex.ExecContext(ctx, "SAVEPOINT migra_init"); err != nil {
 		return err
 	}
 
-	return tx.Commit()
-}
+	if _, err := ex.ExecContext(ctx, stmt); err != nil {
+		if !isConcurrentCreate(err) {
+			return err
+		}
 
-// PushMany pushes multiple migrations and returns first error encountered
-func (m *Migra) PushMany(ctx context.Context, migrations []Migration) error {
-	for i := range migrations {
-		if err := m.Push(ctx, &migrations[i]); err != nil {
+		if _, err := ex.ExecContext(ctx, "ROLLBACK TO SAVEPOINT migra_init"); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	_, err := ex.ExecContext(ctx, "RELEASE SAVEPOINT migra_init")
+	return err
 }
 
-// PushFile pushes a migration from a file
-func (m *Migra) PushFile(ctx context.Context, filepath string) error {
-	v := viper.New()
-	v.SetConfigFile(filepath)
-	if err := v.ReadInConfig(); err != nil {
-		return err
-	}
-
-	var migration Migration
+// InitTimeout creates the migration table like CreateMigrationTable, but gives up after d and returns ErrInitTimeout.
+// It keeps readiness checks from hanging when the migration table is locked or the database is slow.
+func (m *Migra) InitTimeout(ctx context.Context, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
 
-	if err := v.Unmarshal(&migration); err != nil {
-		return err
+	err := m.CreateMigrationTable(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s: %w", ErrInitTimeout, d, err)
 	}
 
-	return m.Push(ctx, &migration)
+	return err
 }
 
-// PushFileFS pushes a file with given name from the filesystem
-func (m *Migra) PushFileFS(ctx context.Context, filesystem fs.FS, filepath string) error {
-	v := viper.New()
+// InitSQL returns the statements executed by CreateMigrationTable for the current schema and table.
+// This includes the statements which bring migration tables created by earlier versions up to date,
+// except for storing the keys of migrations pushed before keys existed, which CreateMigrationTable computes afterwards.
+func (m *Migra) InitSQL() []string {
+	id, position := "SERIAL", "SERIAL"
+	if m.identityStyle == IdentityGenerated {
+		// positions are renumbered by Reposition so they can not be generated always
+		id, position = "INTEGER GENERATED ALWAYS AS IDENTITY", "INTEGER GENERATED BY DEFAULT AS IDENTITY"
+	}
 
-	f, err := filesystem.Open(path.Join(".", filepath))
+	stmts := []string{
+		fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", m.schemaName),
+		m.query(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS {table} (
+		{id} %s PRIMARY KEY,
+		{name} TEXT NOT NULL UNIQUE,
+		{description} TEXT,
+		{up} TEXT,
+		{down} TEXT,
+		{position} %s NOT NULL,
+		{migrated_at} TIMESTAMPTZ,
+		{checksum} TEXT,
+		{duration_ms} BIGINT,
+		{irreversible} BOOLEAN NOT NULL DEFAULT FALSE,
+		{skipped} BOOLEAN NOT NULL DEFAULT FALSE,
+		{key} TEXT,
+		{no_transaction} BOOLEAN NOT NULL DEFAULT FALSE,
+		{meta} JSONB,
+		{session_sql} JSONB,
+		{status} TEXT NOT NULL DEFAULT 'pending'
+	);`, id, position)),
+		m.query("ALTER TABLE {table} ADD COLUMN IF NOT EXISTS {checksum} TEXT"),
+		m.query("ALTER TABLE {table} ALTER COLUMN {name} TYPE TEXT"),
+		m.query("ALTER TABLE {table} ADD COLUMN IF NOT EXISTS {duration_ms} BIGINT"),
+		m.query("ALTER TABLE {table} ADD COLUMN IF NOT EXISTS {irreversible} BOOLEAN NOT NULL DEFAULT FALSE"),
+		m.query("ALTER TABLE {table} ADD COLUMN IF NOT EXISTS {skipped} BOOLEAN NOT NULL DEFAULT FALSE"),
+		m.query("ALTER TABLE {table} ADD COLUMN IF NOT EXISTS {key} TEXT"),
+		m.query("ALTER TABLE {table} ADD COLUMN IF NOT EXISTS {no_transaction} BOOLEAN NOT NULL DEFAULT FALSE"),
+		m.query("ALTER TABLE {table} ADD COLUMN IF NOT EXISTS {meta} JSONB"),
+		m.query("ALTER TABLE {table} ADD COLUMN IF NOT EXISTS {session_sql} JSONB"),
+		m.query("ALTER TABLE {table} ADD COLUMN IF NOT EXISTS {status} TEXT NOT NULL DEFAULT 'pending'"),
+		m.query("UPDATE {table} SET {status} = CASE WHEN {skipped} THEN 'skipped' ELSE 'applied' END WHERE {status} = 'pending' AND {migrated_at} IS NOT NULL"),
+	}
 
-	if err != nil {
-		return err
+	if m.trackSchema {
+		stmts = append(stmts, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id SERIAL PRIMARY KEY,
+		fingerprint TEXT NOT NULL,
+		captured_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);`, m.SchemaTable()))
 	}
 
-	defer f.Close()
-	ext := path.Ext(filepath)
-	v.SetConfigType(ext[1:])
+	return stmts
+}
 
-	if err := v.ReadConfig(f); err != nil {
+// backfillKeys stores the keys of migrations pushed before keys were stored.
+// The keys are computed here rather than in sql since the sha256 function requires PostgreSQL 11.
+func (m *Migra) backfillKeys(ctx context.Context) error {
+	rows, err := m.execer().QueryContext(ctx, m.query("SELECT {name} FROM {table} WHERE {key} IS NULL"))
+	if err != nil {
 		return err
 	}
 
-	var migration Migration
-	if err := v.Unmarshal(&migration); err != nil {
-		return err
-	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
 
-	return m.Push(ctx, &migration)
-}
+		names = append(names, name)
+	}
 
-// PushDir pushes all migrations inside a directory
-func (m *Migra) PushDir(ctx context.Context, dirpath string) error {
-	entries, err := os.ReadDir(dirpath)
-	if err != nil {
+	rows.Close()
+	if err := rows.Err(); err != nil {
 		return err
 	}
 
-	for i := range entries {
-		filepath := path.Join(dirpath, entries[i].Name())
-		if err := m.PushFile(ctx, filepath); err != nil {
+	stmt := m.query("UPDATE {table} SET {key} = $2 WHERE {name} = $1 AND {key} IS NULL")
+	for _, name := range names {
+		if _, err := m.execer().ExecContext(ctx, stmt, name, migrationKey(name)); err != nil {
 			return err
 		}
 	}
@@ -246,166 +536,1020 @@ func (m *Migra) PushDir(ctx context.Context, dirpath string) error {
 	return nil
 }
 
-func (m *Migra) PushDirFS(ctx context.Context, filesystem fs.FS, dirpath string) error {
-	// here is where we read
-	entries, err := fs.ReadDir(filesystem, dirpath)
-	if err != nil {
-		return err
+// schemaExists reports whether the schema of the migration table exists
+func (m *Migra) schemaExists(ctx context.Context) (bool, error) {
+	var (
+		exists bool
+		stmt   = "SELECT EXISTS (SELECT 1 FROM information_schema.schemata WHERE schema_name = $1)"
+	)
+
+	if err := m.execer().QueryRowContext(ctx, stmt, m.schemaName).Scan(&exists); err != nil {
+		return false, err
 	}
 
-	for _, entry := range entries {
-		filename := path.Join(dirpath, entry.Name())
+	return exists, nil
+}
 
-		if entry.IsDir() {
-			if err := m.PushDirFS(ctx, filesystem, filename); err != nil {
-				return err
-			}
-		} else {
-			if err := m.PushFileFS(ctx, filesystem, filename); err != nil {
-				return err
-			}
-		}
+// ServerVersion returns the version reported by the database server
+func (m *Migra) ServerVersion(ctx context.Context) (string, error) {
+	var version string
+	if err := m.execer().QueryRowContext(ctx, "SELECT version()").Scan(&version); err != nil {
+		return "", err
 	}
 
-	return nil
+	return version, nil
 }
 
-// PushFS pushes all migrations in a directory using fs.FS
-func (m *Migra) PushFS(ctx context.Context, filesystem fs.FS) error {
-	return m.PushDirFS(ctx, filesystem, ".")
+// TableExists reports whether the migration table exists, which is false without an error when CreateMigrationTable was not called yet
+func (m *Migra) TableExists(ctx context.Context) (bool, error) {
+	var exists bool
+	if err := m.execer().QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", m.MigrationTable()).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
 }
 
-// Pop reverts the last migration
-func (m *Migra) Pop(ctx context.Context) error {
-	tx, err := m.db.Begin()
-	if err != nil {
-		return err
+// DropMigrationTable
+func (m *Migra) DropMigrationTable(ctx context.Context) error {
+	_, err := m.execer().ExecContext(ctx, m.query("DROP TABLE {table}"))
+	return err
+}
+
+// Push adds a migration to the database and executes it
+func (m *Migra) Push(ctx context.Context, migration *Migration) error {
+	if m.lockSet {
+		if err := checkLocked(m.lock, []Migration{*migration}); err != nil {
+			return err
+		}
 	}
 
-	defer tx.Rollback()
+	return m.withDistributedLock(ctx, func(ctx context.Context) error {
+		return m.push(ctx, m.db, migration)
+	})
+}
 
-	stmt := fmt.Sprintf(`SELECT name, down FROM %s ORDER BY position DESC`, m.MigrationTable())
-	row := tx.QueryRowContext(ctx, stmt)
+func (m *Migra) push(ctx context.Context, c conn, migration *Migration) error {
+	return m.pushTx(ctx, c, []Migration{*migration})
+}
 
-	var (
-		name string
-		down string
-	)
+// check validates a migration before it is pushed
+func (m *Migra) check(migration *Migration) error {
+	if migration.Name == "" {
+		return errors.New("migration name is required")
+	}
 
-	if err := row.Scan(&name, &down); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return ErrNoMigration
-		}
+	if migration.Up == "" && migration.Batch == nil {
+		return errors.New("up sql is required")
+	}
 
+	if err := migration.Batch.check(migration.Name); err != nil {
 		return err
 	}
 
-	if _, err := tx.ExecContext(ctx, down); err != nil {
-		return err
+	if migration.NoTransaction && len(migration.SessionSQL) > 0 {
+		return fmt.Errorf("migration %s: session sql requires a transaction and can not be used with no_transaction", migration.Name)
 	}
 
-	stmt = fmt.Sprintf("DELETE FROM %s WHERE name = $1", m.MigrationTable())
-	if _, err := tx.ExecContext(ctx, stmt, name); err != nil {
-		return err
+	if m.requireDown && migration.Down == "" && !migration.Irreversible {
+		return fmt.Errorf("%w: %s", ErrNoDown, migration.Name)
 	}
 
-	return tx.Commit()
+	return nil
 }
 
-// PopAll reverts all migrations
-func (m *Migra) PopAll(ctx context.Context) (int, error) {
-	var n int
+// SetRequireDown makes pushes reject migrations without down sql unless they are marked irreversible,
+// which enforces that every migration can be reverted
+func (m *Migra) SetRequireDown(require bool) *Migra {
+	m.requireDown = require
+	return m
+}
 
-	for {
-		if err := m.Pop(ctx); err != nil {
-			if errors.Is(err, ErrNoMigration) {
-				if n == 0 {
-					return 0, ErrNoMigration
-				}
+// pushTx pushes the migrations which have not been pushed yet within a single transaction
+func (m *Migra) pushTx(ctx context.Context, c conn, migrations []Migration) error {
+	for i := range migrations {
+		if err := m.check(&migrations[i]); err != nil {
+			return err
+		}
+	}
+
+	// migrations which can not run in a transaction split the transaction
+	for i := range migrations {
+		if migrations[i].Batch != nil {
+			if err := m.pushTx(ctx, c, migrations[:i]); err != nil {
+				return err
+			}
 
-				return n, nil
+			if err := m.pushBatched(ctx, c, &migrations[i]); err != nil {
+				return err
 			}
 
-			return n, err
+			return m.pushTx(ctx, c, migrations[i+1:])
 		}
-		n++
-	}
-}
 
-// PopUntil pops until a migration with given name is reached
-func (m *Migra) PopUntil(ctx context.Context, name string) error {
-	var (
-		mig *Migration
-		err error
-	)
+		if migrations[i].NoTransaction {
+			if err := m.pushTx(ctx, c, migrations[:i]); err != nil {
+				return err
+			}
 
-	for {
-		mig, err = m.Latest(ctx)
+			if err := m.pushNoTx(ctx, c, &migrations[i]); err != nil {
+				return err
+			}
 
-		if err != nil {
-			return err
+			return m.pushTx(ctx, c, migrations[i+1:])
 		}
 
-		if mig.Name == name {
-			return nil
-		}
+		// session settings last until the end of the transaction, so they must not apply to other migrations
+		if len(migrations[i].SessionSQL) > 0 && len(migrations) > 1 {
+			if err := m.pushTx(ctx, c, migrations[:i]); err != nil {
+				return err
+			}
 
-		if err := m.Pop(ctx); err != nil {
-			return err
+			if err := m.pushTx(ctx, c, migrations[i:i+1]); err != nil {
+				return err
+			}
+
+			return m.pushTx(ctx, c, migrations[i+1:])
 		}
 	}
-}
 
-// Latest returns the latest migration executed
-func (m *Migra) Latest(ctx context.Context) (*Migration, error) {
-	sql := fmt.Sprintf(`SELECT id, name, description, up, down, position, migrated_at FROM %s ORDER BY position DESC`, m.MigrationTable())
-	row := m.db.QueryRowContext(ctx, sql)
+	return m.commitTx(ctx, c, migrations)
+}
 
-	if err := row.Err(); err != nil {
-		return nil, err
+// commitTx pushes the migrations which have not been pushed yet within a single transaction,
+// without checking them or splitting the transaction, see pushTx
+func (m *Migra) commitTx(ctx context.Context, c conn, migrations []Migration) error {
+	if len(migrations) == 0 {
+		return nil
 	}
 
-	var mig Migration
-	if err := row.Scan(
-		&mig.ID,
-		&mig.Name,
-		&mig.Description,
-		&mig.Up,
-		&mig.Down,
-		&mig.Position,
-		&mig.MigratedAt); err != nil {
-		return nil, err
+	tx, err := m.begin(ctx, c)
+	if err != nil {
+		return err
 	}
 
-	return &mig, nil
-}
+	defer tx.Rollback()
+
+	type applied struct {
+		name    string
+		elapsed time.Duration
+	}
+
+	var done []applied
+	for i := range migrations {
+		name := m.normalizeName(migrations[i].Name)
+		if !migrations[i].InEnvironment(m.environment) {
+			m.logf("skipping migration %s: not included in environment %q", name, m.environment)
+			continue
+		}
+
+		if m.pushed(ctx, tx, name) {
+			if !migrations[i].Repeatable && !migrations[i].AllowRerun {
+				if err := m.checkConflict(ctx, tx, name, &migrations[i]); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			elapsed, changed, err := m.reapply(ctx, tx, name, &migrations[i])
+			if err != nil {
+				m.observe().MigrationFailed(name, err)
+				return err
+			}
+
+			if changed {
+				done = append(done, applied{name, elapsed})
+			}
+
+			continue
+		}
+
+		elapsed, err := m.execute(ctx, tx, name, &migrations[i])
+		if err != nil {
+			m.observe().MigrationFailed(name, err)
+			return err
+		}
+
+		done = append(done, applied{name, elapsed})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, a := range done {
+		m.observe().MigrationApplied(a.name, a.elapsed)
+	}
+
+	return nil
+}
+
+// execute records the migration under name and executes its up sql within the transaction, returning how long the up sql took
+func (m *Migra) execute(ctx context.Context, tx Execer, name string, migration *Migration) (time.Duration, error) {
+	if err := m.checkAccess(name, migration); err != nil {
+		return 0, err
+	}
+
+	if err := m.checkDDL(name, migration.Up); err != nil {
+		return 0, err
+	}
+
+	if err := m.execSession(ctx, tx, name, migration.SessionSQL); err != nil {
+		return 0, err
+	}
+
+	// insert record of the migration
+	meta, err := encodeMeta(migration.Meta)
+	if err != nil {
+		return 0, err
+	}
+
+	session, err := encodeSessionSQL(migration.SessionSQL)
+	if err != nil {
+		return 0, err
+	}
+
+	sql := m.query("INSERT INTO {table} ({name}, {description}, {up}, {down}, {checksum}, {irreversible}, {key}, {meta}, {session_sql}) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)")
+	up, down := m.recordedSQL(migration.Up, migration.Down)
+	if _, err := tx.ExecContext(ctx, sql, name, migration.Description, up, down, migration.ComputeChecksum(), migration.Irreversible, migrationKey(name), meta, session); err != nil {
+		return 0, err
+	}
+
+	run, err := m.precheck(ctx, tx, name, migration)
+	if err != nil {
+		return 0, err
+	}
+
+	// execute up migration unless the precheck does not hold
+	var elapsed time.Duration
+	if run {
+		start := time.Now()
+		if run, err = m.execUpDuplicate(ctx, tx, name, migration.Up); err != nil {
+			return 0, err
+		}
+
+		elapsed = time.Since(start)
+
+		if run {
+			if err := m.postCheck(ctx, tx, name, migration); err != nil {
+				return 0, err
+			}
+		}
+	} else {
+		m.logf("skipping migration %s: precheck is false", name)
+	}
+
+	// set migration as executed
+	status := StatusApplied
+	if !run {
+		status = StatusSkipped
+	}
+
+	sql = m.query("UPDATE {table} SET {migrated_at} = NOW(), {duration_ms} = $2, {skipped} = $3, {status} = $4 WHERE {name} = $1")
+	if _, err := tx.ExecContext(ctx, sql, name, elapsed.Milliseconds(), !run, status); err != nil {
+		return 0, err
+	}
+
+	if err := m.captureSchema(ctx, tx); err != nil {
+		return 0, err
+	}
+
+	return elapsed, nil
+}
+
+// precheck reports whether the up sql of a migration should be executed by running its precheck sql,
+// which must return a single boolean. Migrations without precheck sql are always executed.
+func (m *Migra) precheck(ctx context.Context, tx Execer, name string, migration *Migration) (bool, error) {
+	if migration.Precheck == "" {
+		return true, nil
+	}
+
+	var run bool
+	if err := tx.QueryRowContext(ctx, migration.Precheck).Scan(&run); err != nil {
+		return false, wrapExecError(name, 0, migration.Precheck, err)
+	}
+
+	return run, nil
+}
+
+// postCheck runs the post check sql of a migration after its up sql, which must return a single boolean,
+// and returns ErrPostCheckFailed when it is false. Migrations without post check sql always pass.
+func (m *Migra) postCheck(ctx context.Context, tx Execer, name string, migration *Migration) error {
+	if migration.PostCheck == "" {
+		return nil
+	}
+
+	var ok bool
+	if err := tx.QueryRowContext(ctx, migration.PostCheck).Scan(&ok); err != nil {
+		return wrapExecError(name, 0, migration.PostCheck, err)
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrPostCheckFailed, name)
+	}
+
+	return nil
+}
+
+// UpdateRecorded replaces the recorded description, up and down sql, checksum, metadata and session sql of an already pushed migration
+// without executing anything. It is meant for deliberately reconciling the migration table with edited migration files.
+// ErrNoMigration is returned when no migration with the name was pushed.
+func (m *Migra) UpdateRecorded(ctx context.Context, migration *Migration) error {
+	meta, err := encodeMeta(migration.Meta)
+	if err != nil {
+		return err
+	}
+
+	session, err := encodeSessionSQL(migration.SessionSQL)
+	if err != nil {
+		return err
+	}
+
+	stmt := m.query("UPDATE {table} SET {description} = $2, {up} = $3, {down} = $4, {checksum} = $5, {meta} = $6, {session_sql} = $7 WHERE {name} = $1")
+	up, down := m.recordedSQL(migration.Up, migration.Down)
+	res, err := m.execer().ExecContext(ctx, stmt, m.normalizeName(migration.Name), migration.Description, up, down, migration.ComputeChecksum(), meta, session)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrNoMigration, migration.Name)
+	}
+
+	return nil
+}
+
+// pushed reports whether a migration with the given normalized name was already pushed, which failed migrations were not, see StatusFailed
+func (m *Migra) pushed(ctx context.Context, c Execer, name string) bool {
+	var (
+		sql   = m.query("SELECT {name} FROM {table} WHERE {name} = $1 AND {status} <> 'failed'")
+		found string
+		row   = c.QueryRowContext(ctx, sql, name)
+	)
+
+	row.Scan(&found)
+	return found == name
+}
+
+// PushMany pushes multiple migrations and returns first error encountered.
+// The migrations are pushed as a batch, see SetPreBatchSQL, SetPostBatchSQL and SetCheckpointEvery.
+func (m *Migra) PushMany(ctx context.Context, migrations []Migration) error {
+	if m.lockSet {
+		if err := checkLocked(m.lock, migrations); err != nil {
+			return err
+		}
+	}
+
+	return m.pushMany(ctx, migrations, false)
+}
+
+// pushMany pushes the migrations as a batch, first recording the baseline when baseline is true, see SetBaseline
+func (m *Migra) pushMany(ctx context.Context, migrations []Migration, baseline bool) error {
+	size := m.checkpointEvery
+	if size < 1 {
+		size = 1
+	}
+
+	return m.batch(ctx, func(ctx context.Context, c conn) error {
+		if baseline {
+			if err := m.recordBaseline(ctx, c); err != nil {
+				return err
+			}
+		}
+
+		for i := 0; i < len(migrations); i += size {
+			if err := m.pushTx(ctx, c, migrations[i:min(i+size, len(migrations))]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// PushTagged pushes only the migrations tagged with tag, other migrations are skipped
+func (m *Migra) PushTagged(ctx context.Context, migrations []Migration, tag string) error {
+	var tagged []Migration
+	for i := range migrations {
+		if migrations[i].HasTag(tag) {
+			tagged = append(tagged, migrations[i])
+		}
+	}
+
+	return m.PushMany(ctx, tagged)
+}
+
+// PushNames pushes only the migrations of source with the given names, in the order they appear in source.
+// An error is returned before anything is pushed when a name is not found in source.
+func (m *Migra) PushNames(ctx context.Context, source []Migration, names ...string) error {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[m.normalizeName(name)] = false
+	}
+
+	var selected []Migration
+	for i := range source {
+		name := m.normalizeName(source[i].Name)
+		if _, ok := wanted[name]; ok {
+			wanted[name] = true
+			selected = append(selected, source[i])
+		}
+	}
+
+	for _, name := range names {
+		if !wanted[m.normalizeName(name)] {
+			return fmt.Errorf("migration %s not found in source", name)
+		}
+	}
+
+	return m.PushMany(ctx, selected)
+}
+
+// PushFile pushes a migration from a file
+func (m *Migra) PushFile(ctx context.Context, filepath string) error {
+	migration, err := loadFile(filepath, m.decodeHook)
+	if err != nil {
+		return err
+	}
+
+	return m.Push(ctx, migration)
+}
+
+// PushFileMany pushes the migrations defined in a file in order, see LoadFileMany for the file format
+func (m *Migra) PushFileMany(ctx context.Context, filepath string) error {
+	return m.Apply(ctx, FileSource{Path: filepath, DecodeHook: m.decodeHook})
+}
+
+// PushFileFS pushes a file with given name from the filesystem
+func (m *Migra) PushFileFS(ctx context.Context, filesystem fs.FS, filepath string) error {
+	migration, err := loadFileFS(filesystem, filepath, m.decodeHook)
+	if err != nil {
+		return err
+	}
+
+	return m.Push(ctx, migration)
+}
+
+// PushDir pushes all migrations inside a directory, see Apply
+func (m *Migra) PushDir(ctx context.Context, dirpath string) error {
+	return m.Apply(ctx, DirSource{Path: dirpath, DecodeHook: m.decodeHook})
+}
+
+// PushDirFS pushes all migrations inside a directory of the filesystem, including those in subdirectories, see Apply
+func (m *Migra) PushDirFS(ctx context.Context, filesystem fs.FS, dirpath string) error {
+	return m.Apply(ctx, FSSource{FS: filesystem, Dir: dirpath, DecodeHook: m.decodeHook})
+}
+
+// PushFS pushes all migrations in a directory using fs.FS
+func (m *Migra) PushFS(ctx context.Context, filesystem fs.FS) error {
+	return m.PushDirFS(ctx, filesystem, ".")
+}
+
+// Pop reverts the last migration
+func (m *Migra) Pop(ctx context.Context) error {
+	return m.pop(ctx, nil)
+}
+
+// PopWith reverts the last migration using the down sql of the migration with the same name in source,
+// instead of the down sql stored in the migration table. This is required for migrations pushed while
+// storing sql was disabled, see SetStoreSQL.
+func (m *Migra) PopWith(ctx context.Context, source []Migration) error {
+	if source == nil {
+		source = []Migration{}
+	}
+
+	return m.pop(ctx, source)
+}
+
+// pop reverts the last migration using the down sql from source, or the stored down sql when source is nil
+func (m *Migra) pop(ctx context.Context, source []Migration) error {
+	tx, err := m.begin(ctx, m.db)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	stmt := m.query(`SELECT {name}, {down}, {irreversible}, {skipped}, {no_transaction}, {session_sql} FROM {table} ORDER BY {position} DESC, {id} DESC`)
+	row := tx.QueryRowContext(ctx, stmt)
+
+	var (
+		name          string
+		stored        sql.NullString
+		irreversible  bool
+		skipped       bool
+		noTransaction bool
+		session       sql.NullString
+	)
+
+	if err := row.Scan(&name, &stored, &irreversible, &skipped, &noTransaction, &session); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoMigration
+		}
+
+		return err
+	}
+
+	if err := m.checkReversible(name, irreversible); err != nil {
+		return err
+	}
+
+	// the up sql of skipped migrations was never executed so there is nothing to revert
+	var down string
+	if !skipped {
+		down, err = m.downSQL(name, stored, source)
+		if err != nil {
+			return err
+		}
+	}
+
+	if noTransaction {
+		tx.Rollback()
+		if err := m.revertNoTx(ctx, name, down); err != nil {
+			return err
+		}
+
+		m.observe().MigrationReverted(name)
+		return nil
+	}
+
+	sessionSQL, err := decodeSessionSQL(session)
+	if err != nil {
+		return err
+	}
+
+	if err := m.revert(ctx, tx, name, down, sessionSQL); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	m.observe().MigrationReverted(name)
+	return nil
+}
+
+// SetForceIrreversible allows Pop, PopWith and PopAll to revert migrations marked as irreversible by executing their down sql anyway
+func (m *Migra) SetForceIrreversible(force bool) *Migra {
+	m.forceIrreversible = force
+	return m
+}
+
+// CanPop reports whether the latest migration can be reverted by Pop, and the reason when it can not,
+// such as when there are no migrations or the latest migration is irreversible or has no down sql.
+// Skipped migrations can always be popped since their up sql was never executed.
+func (m *Migra) CanPop(ctx context.Context) (bool, string, error) {
+	latest, err := m.Latest(ctx)
+	if errors.Is(err, ErrNoMigration) {
+		return false, "no migrations", nil
+	}
+
+	if err != nil {
+		return false, "", err
+	}
+
+	if m.checkReversible(latest.Name, latest.Irreversible) != nil {
+		return false, fmt.Sprintf("latest migration %s is irreversible", latest.Name), nil
+	}
+
+	if !latest.Skipped && latest.Down == "" {
+		return false, fmt.Sprintf("latest migration %s has no down sql", latest.Name), nil
+	}
+
+	return true, "", nil
+}
+
+// checkReversible returns ErrIrreversible if the migration is irreversible and popping is not forced
+func (m *Migra) checkReversible(name string, irreversible bool) error {
+	if irreversible && !m.forceIrreversible {
+		return fmt.Errorf("%w: %s", ErrIrreversible, name)
+	}
+
+	return nil
+}
+
+// downSQL returns the down sql of the named migration from source, or the stored down sql when source is nil
+func (m *Migra) downSQL(name string, stored sql.NullString, source []Migration) (string, error) {
+	if source != nil {
+		for i := range source {
+			if m.normalizeName(source[i].Name) == name {
+				return source[i].Down, nil
+			}
+		}
+
+		return "", fmt.Errorf("migration %s not found in source", name)
+	}
+
+	if !stored.Valid {
+		return "", fmt.Errorf("%w: %s", ErrSQLNotStored, name)
+	}
+
+	return stored.String, nil
+}
+
+// revert executes the session sql and down sql of a migration, if it has down sql, and removes it from the migration table
+func (m *Migra) revert(ctx context.Context, tx Execer, name, down string, session []string) error {
+	if down != "" {
+		if err := m.execSession(ctx, tx, name, session); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, down); err != nil {
+			return wrapExecError(name, 0, down, err)
+		}
+	}
+
+	stmt := m.query("DELETE FROM {table} WHERE {name} = $1")
+	if _, err := tx.ExecContext(ctx, stmt, name); err != nil {
+		return err
+	}
+
+	return m.captureSchema(ctx, tx)
+}
+
+// PlanPop returns the migrations in the order they would be reverted by PopAll without executing anything.
+// Each migration includes the down sql that would be executed.
+func (m *Migra) PlanPop(ctx context.Context) ([]Migration, error) {
+	sql := m.query(`SELECT {columns} FROM {table} ORDER BY {position} DESC, {id} DESC`)
+	return m.queryMigrations(ctx, sql)
+}
+
+// RunDown executes the down sql of the migrations in reverse order, each in its own transaction.
+// It is a tool for testing down migrations and does not read or update the migration table,
+// so the history is left unchanged. Migrations without down sql are skipped.
+func (m *Migra) RunDown(ctx context.Context, migrations []Migration) error {
+	for i := len(migrations) - 1; i >= 0; i-- {
+		mig := &migrations[i]
+		if mig.Down == "" {
+			continue
+		}
+
+		tx, err := m.begin(ctx, m.db)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+			tx.Rollback()
+			return wrapExecError(mig.Name, 0, mig.Down, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PopAll reverts all migrations, each in its own transaction.
+// The migrations are fetched once up front instead of querying for the latest migration before every pop.
+// The number of migrations reverted is returned even when an error occurs, such as the context being cancelled.
+func (m *Migra) PopAll(ctx context.Context) (int, error) {
+	type reversal struct {
+		name          string
+		down          sql.NullString
+		irreversible  bool
+		skipped       bool
+		noTransaction bool
+		session       sql.NullString
+	}
+
+	stmt := m.query("SELECT {name}, {down}, {irreversible}, {skipped}, {no_transaction}, {session_sql} FROM {table} ORDER BY {position} DESC, {id} DESC")
+	rows, err := m.execer().QueryContext(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+
+	var reversals []reversal
+	for rows.Next() {
+		var r reversal
+		if err := rows.Scan(&r.name, &r.down, &r.irreversible, &r.skipped, &r.noTransaction, &r.session); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		reversals = append(reversals, r)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(reversals) == 0 {
+		return 0, ErrNoMigration
+	}
+
+	for n, r := range reversals {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		if err := m.checkReversible(r.name, r.irreversible); err != nil {
+			return n, err
+		}
+
+		var down string
+		if !r.skipped {
+			down, err = m.downSQL(r.name, r.down, nil)
+			if err != nil {
+				return n, err
+			}
+		}
+
+		if r.noTransaction {
+			if err := m.revertNoTx(ctx, r.name, down); err != nil {
+				return n, err
+			}
+
+			m.observe().MigrationReverted(r.name)
+			continue
+		}
+
+		session, err := decodeSessionSQL(r.session)
+		if err != nil {
+			return n, err
+		}
+
+		tx, err := m.begin(ctx, m.db)
+		if err != nil {
+			return n, err
+		}
+
+		if err := m.revert(ctx, tx, r.name, down, session); err != nil {
+			tx.Rollback()
+			return n, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return n, err
+		}
+
+		m.observe().MigrationReverted(r.name)
+	}
+
+	return len(reversals), nil
+}
+
+// PopUntil pops until a migration with given name is reached
+func (m *Migra) PopUntil(ctx context.Context, name string) error {
+	var (
+		mig *Migration
+		err error
+	)
+
+	for {
+		mig, err = m.Latest(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if m.normalizeName(mig.Name) == m.normalizeName(name) {
+			return nil
+		}
+
+		if err := m.Pop(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// GetByName returns the pushed migration with the name. ErrNoMigration is returned when no migration with the name was pushed.
+func (m *Migra) GetByName(ctx context.Context, name string) (*Migration, error) {
+	var (
+		mig  Migration
+		stmt = m.query(`SELECT {columns} FROM {table} WHERE {name} = $1`)
+	)
+
+	if err := scanMigration(m.execer().QueryRowContext(ctx, stmt, m.normalizeName(name)), &mig); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrNoMigration, name)
+		}
+
+		return nil, err
+	}
+
+	return &mig, nil
+}
+
+// Latest returns the latest migration executed. ErrNoMigration is returned when there are no migrations.
+func (m *Migra) Latest(ctx context.Context) (*Migration, error) {
+	stmt := m.query(`SELECT {columns} FROM {table} ORDER BY {position} DESC, {id} DESC`)
+	row := m.execer().QueryRowContext(ctx, stmt)
+
+	if err := row.Err(); err != nil {
+		return nil, err
+	}
+
+	var mig Migration
+	if err := scanMigration(row, &mig); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoMigration
+		}
+
+		return nil, err
+	}
+
+	return &mig, nil
+}
 
 // List returns all the executed migrations
 func (m *Migra) List(ctx context.Context) ([]Migration, error) {
-	sql := fmt.Sprintf(`SELECT id, name, description, up, down, position, migrated_at FROM %s ORDER BY position ASC`, m.MigrationTable())
-	rows, err := m.db.QueryContext(ctx, sql)
+	migrations := make([]Migration, 0)
+	err := m.Each(ctx, func(mig Migration) error {
+		migrations = append(migrations, mig)
+		return nil
+	})
+
+	return migrations, err
+}
 
+// AppliedKeys returns the names of the applied migrations in order of position.
+// Only the name column is selected, which makes it a cheap way to compare the database with the names of source migrations.
+func (m *Migra) AppliedKeys(ctx context.Context) ([]string, error) {
+	stmt := m.query("SELECT {name} FROM {table} WHERE {migrated_at} IS NOT NULL ORDER BY {position} ASC, {id} ASC")
+	rows, err := m.execer().QueryContext(ctx, stmt)
 	if err != nil {
 		return nil, err
 	}
 
 	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// Each calls fn for every executed migration in order of position, without loading all migrations into memory.
+// Iteration stops at the first error returned by fn, which is then returned by Each.
+func (m *Migra) Each(ctx context.Context, fn func(m Migration) error) error {
+	sql := m.query(`SELECT {columns} FROM {table} ORDER BY {position} ASC, {id} ASC`)
+	return m.eachMigration(ctx, fn, sql)
+}
+
+// ListUnapplied returns the migrations which are recorded in the migration table but have not been applied, ordered by position
+func (m *Migra) ListUnapplied(ctx context.Context) ([]Migration, error) {
+	sql := m.query(`SELECT {columns} FROM {table} WHERE {migrated_at} IS NULL ORDER BY {position} ASC, {id} ASC`)
+	return m.queryMigrations(ctx, sql)
+}
+
+// AppliedSince returns the migrations applied after since, ordered by the time they were applied.
+// Migrations which are recorded but have not been applied are never included.
+func (m *Migra) AppliedSince(ctx context.Context, since time.Time) ([]Migration, error) {
+	sql := m.query(`SELECT {columns} FROM {table} WHERE {migrated_at} > $1 ORDER BY {migrated_at} ASC, {position} ASC, {id} ASC`)
+	return m.queryMigrations(ctx, sql, since)
+}
+
+// AppliedBetween returns the migrations applied at or after from and before to, ordered by the time they were applied.
+// A zero from or to leaves that end of the range open, so AppliedBetween(ctx, from, time.Time{}) returns every migration applied since from.
+func (m *Migra) AppliedBetween(ctx context.Context, from, to time.Time) ([]Migration, error) {
+	var (
+		conds = []string{"{migrated_at} IS NOT NULL"}
+		args  []any
+	)
+
+	if !from.IsZero() {
+		args = append(args, from)
+		conds = append(conds, fmt.Sprintf("{migrated_at} >= $%d", len(args)))
+	}
+
+	if !to.IsZero() {
+		args = append(args, to)
+		conds = append(conds, fmt.Sprintf("{migrated_at} < $%d", len(args)))
+	}
+
+	sql := m.query(`SELECT {columns} FROM {table} WHERE ` + strings.Join(conds, " AND ") + ` ORDER BY {migrated_at} ASC, {position} ASC, {id} ASC`)
+	return m.queryMigrations(ctx, sql, args...)
+}
+
+// ListRecent returns the last n migrations by position in ascending order of position.
+// All migrations are returned when n exceeds their number, and none when n is not positive.
+func (m *Migra) ListRecent(ctx context.Context, n int) ([]Migration, error) {
+	if n <= 0 {
+		return make([]Migration, 0), nil
+	}
+
+	sql := m.query(`SELECT * FROM (SELECT {columns} FROM {table} ORDER BY {position} DESC, {id} DESC LIMIT $1) recent ORDER BY {position} ASC, {id} ASC`)
+	return m.queryMigrations(ctx, sql, n)
+}
+
+// ListByPrefix returns the migrations whose name starts with prefix, ordered by position
+func (m *Migra) ListByPrefix(ctx context.Context, prefix string) ([]Migration, error) {
+	sql := m.query(`SELECT {columns} FROM {table} WHERE {name} LIKE $1 || '%' ESCAPE '\' ORDER BY {position} ASC, {id} ASC`)
+	return m.queryMigrations(ctx, sql, escapeLike(prefix))
+}
+
+// escapeLike escapes the LIKE metacharacters in s with a backslash
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// migrationColumns are the columns selected when scanning a migration, substituted for {columns} by query
+const migrationColumns = "{id}, {name}, {description}, {up}, {down}, {position}, {migrated_at}, {checksum}, {duration_ms}, {irreversible}, {skipped}, {no_transaction}, {meta}, {session_sql}, {status}"
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanMigration scans the migrationColumns of a row into mig
+func scanMigration(row scanner, mig *Migration) error {
+	var (
+		up         sql.NullString
+		down       sql.NullString
+		migratedAt sql.NullTime
+		checksum   sql.NullString
+		durationMS sql.NullInt64
+		meta       sql.NullString
+		session    sql.NullString
+	)
+
+	if err := row.Scan(
+		&mig.ID,
+		&mig.Name,
+		&mig.Description,
+		&up,
+		&down,
+		&mig.Position,
+		&migratedAt,
+		&checksum,
+		&durationMS,
+		&mig.Irreversible,
+		&mig.Skipped,
+		&mig.NoTransaction,
+		&meta,
+		&session,
+		&mig.Status); err != nil {
+		return err
+	}
+
+	sessionSQL, err := decodeSessionSQL(session)
+	if err != nil {
+		return err
+	}
+
+	if meta.Valid {
+		if err := json.Unmarshal([]byte(meta.String), &mig.Meta); err != nil {
+			return err
+		}
+	}
+
+	mig.Up = up.String
+	mig.Down = down.String
+	mig.MigratedAt = migratedAt.Time
+	mig.Checksum = checksum.String
+	mig.Duration = time.Duration(durationMS.Int64) * time.Millisecond
+	mig.SessionSQL = sessionSQL
+	return nil
+}
+
+// queryMigrations returns the migrations selected by the query
+func (m *Migra) queryMigrations(ctx context.Context, query string, args ...any) ([]Migration, error) {
 	migrations := make([]Migration, 0)
+	err := m.eachMigration(ctx, func(mig Migration) error {
+		migrations = append(migrations, mig)
+		return nil
+	}, query, args...)
+
+	return migrations, err
+}
+
+// eachMigration calls fn for every migration selected by the query
+func (m *Migra) eachMigration(ctx context.Context, fn func(m Migration) error, query string, args ...any) error {
+	rows, err := m.execer().QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
 	for rows.Next() {
 		var migration Migration
-		if err := rows.Scan(
-			&migration.ID,
-			&migration.Name,
-			&migration.Description,
-			&migration.Up,
-			&migration.Down,
-			&migration.Position,
-			&migration.MigratedAt); err != nil {
-			return migrations, err
+		if err := scanMigration(rows, &migration); err != nil {
+			return err
 		}
 
-		migrations = append(migrations, migration)
+		if err := fn(migration); err != nil {
+			return err
+		}
 	}
 
-	return migrations, nil
+	return rows.Err()
 }
@@ -0,0 +1,140 @@
+package migra
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// pushNoTx pushes a migration marked with NoTransaction by executing its up sql directly on the connection,
+// for statements such as CREATE INDEX CONCURRENTLY which can not run inside a transaction.
+// Each statement is executed on its own, since the database runs several statements sent at once in an implicit transaction.
+// The precheck is applied like for other migrations, but the duplicate object policy and statement error handler are not,
+// as they rely on savepoints. The migration is recorded after its up sql succeeded. If the up sql or post check fails,
+// the changes already made are not rolled back and the migration is recorded with StatusFailed, see StatusFailed.
+func (m *Migra) pushNoTx(ctx context.Context, c conn, migration *Migration) error {
+	name := m.normalizeName(migration.Name)
+	if !migration.InEnvironment(m.environment) {
+		m.logf("skipping migration %s: not included in environment %q", name, m.environment)
+		return nil
+	}
+
+	if m.pushed(ctx, c, name) {
+		return m.checkConflict(ctx, c, name, migration)
+	}
+
+	if err := m.checkNoTx(name); err != nil {
+		return err
+	}
+
+	if err := m.checkAccess(name, migration); err != nil {
+		return err
+	}
+
+	run, err := m.precheck(ctx, c, name, migration)
+	if err != nil {
+		m.observe().MigrationFailed(name, err)
+		return err
+	}
+
+	var elapsed time.Duration
+	if run {
+		start := time.Now()
+		if err := execEach(ctx, c, name, migration.Up); err != nil {
+			m.observe().MigrationFailed(name, err)
+			return m.recordFailed(ctx, c, name, migration, err)
+		}
+
+		elapsed = time.Since(start)
+
+		// the up sql can not be rolled back, a failed post check records the migration as failed
+		if err := m.postCheck(ctx, c, name, migration); err != nil {
+			m.observe().MigrationFailed(name, err)
+			return m.recordFailed(ctx, c, name, migration, err)
+		}
+	} else {
+		m.logf("skipping migration %s: precheck is false", name)
+	}
+
+	status := StatusApplied
+	if !run {
+		status = StatusSkipped
+	}
+
+	var (
+		up, down = m.recordedSQL(migration.Up, migration.Down)
+		stmt     = m.query(`INSERT INTO {table} ({name}, {description}, {up}, {down}, {checksum}, {irreversible}, {key}, {no_transaction}, {migrated_at}, {duration_ms}, {meta}, {skipped}, {status})
+		VALUES ($1, $2, $3, $4, $5, $6, $7, TRUE, NOW(), $8, $9, $10, $11)`)
+	)
+
+	meta, err := encodeMeta(migration.Meta)
+	if err != nil {
+		return err
+	}
+
+	if err := m.clearFailed(ctx, c, name); err != nil {
+		return err
+	}
+
+	if _, err := c.ExecContext(ctx, stmt, name, migration.Description, up, down, migration.ComputeChecksum(), migration.Irreversible, migrationKey(name), elapsed.Milliseconds(), meta, !run, status); err != nil {
+		m.observe().MigrationFailed(name, err)
+		return err
+	}
+
+	if err := m.captureSchema(ctx, c); err != nil {
+		return err
+	}
+
+	m.observe().MigrationApplied(name, elapsed)
+	return nil
+}
+
+// execEach executes the statements of sql one at a time outside of a transaction
+func execEach(ctx context.Context, c Execer, name, sql string) error {
+	stmts := newStatementReader(strings.NewReader(sql))
+	for i := 1; ; i++ {
+		stmt, err := stmts.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.ExecContext(ctx, stmt); err != nil {
+			return wrapExecError(name, i, stmt, err)
+		}
+	}
+}
+
+// revertNoTx reverts a migration marked with NoTransaction by executing its down sql directly on the database,
+// one statement at a time, and then removing it from the migration table. If the down sql fails part way, or removing the migration fails,
+// the changes already made are not rolled back and have to be cleaned up manually.
+func (m *Migra) revertNoTx(ctx context.Context, name, down string) error {
+	if err := m.checkNoTx(name); err != nil {
+		return err
+	}
+
+	if err := execEach(ctx, m.wrap(m.db), name, down); err != nil {
+		return err
+	}
+
+	stmt := m.query("DELETE FROM {table} WHERE {name} = $1")
+	if _, err := m.wrap(m.db).ExecContext(ctx, stmt, name); err != nil {
+		return err
+	}
+
+	return m.captureSchema(ctx, m.wrap(m.db))
+}
+
+// checkNoTx returns an error when the migration can not run because the Migra is bound to a transaction by InTx
+func (m *Migra) checkNoTx(name string) error {
+	if m.tx != nil {
+		return fmt.Errorf("migration %s can not run in a transaction and can not be used within InTx", name)
+	}
+
+	return nil
+}
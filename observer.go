@@ -0,0 +1,37 @@
+package migra
+
+import "time"
+
+// Observer is notified about migrations being applied and reverted.
+// It can be used to export metrics, such as counters and histograms, without migra depending on a metrics library.
+type Observer interface {
+	// MigrationApplied is called after a migration was pushed, with the time its up sql took to execute
+	MigrationApplied(name string, dur time.Duration)
+
+	// MigrationFailed is called when pushing a migration failed
+	MigrationFailed(name string, err error)
+
+	// MigrationReverted is called after a migration was popped
+	MigrationReverted(name string)
+}
+
+// SetObserver sets the observer which is notified about migrations. A nil observer disables notifications.
+func (m *Migra) SetObserver(o Observer) *Migra {
+	m.observer = o
+	return m
+}
+
+// observe returns the configured observer or a no-op observer when none is set
+func (m *Migra) observe() Observer {
+	if m.observer == nil {
+		return nopObserver{}
+	}
+
+	return m.observer
+}
+
+type nopObserver struct{}
+
+func (nopObserver) MigrationApplied(name string, dur time.Duration) {}
+func (nopObserver) MigrationFailed(name string, err error)          {}
+func (nopObserver) MigrationReverted(name string)                   {}
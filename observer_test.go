@@ -0,0 +1,56 @@
+package migra_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cristosal/migra"
+)
+
+type recordingObserver struct {
+	applied  []string
+	failed   []string
+	reverted []string
+}
+
+func (o *recordingObserver) MigrationApplied(name string, dur time.Duration) {
+	o.applied = append(o.applied, name)
+}
+
+func (o *recordingObserver) MigrationFailed(name string, err error) {
+	o.failed = append(o.failed, name)
+}
+
+func (o *recordingObserver) MigrationReverted(name string) {
+	o.reverted = append(o.reverted, name)
+}
+
+func TestObserver(t *testing.T) {
+	m := getMigra(t)
+	o := &recordingObserver{}
+	m.SetObserver(o)
+
+	if err := m.Push(ctx, &migra.Migration{Name: "Good", Up: "SELECT 1", Down: "SELECT 1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Push(ctx, &migra.Migration{Name: "Bad", Up: "SELEC 1", Down: "SELECT 1"}); err == nil {
+		t.Fatal("expected invalid sql to fail")
+	}
+
+	if err := m.Pop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(o.applied) != 1 || o.applied[0] != "Good" {
+		t.Fatalf("unexpected applied migrations %v", o.applied)
+	}
+
+	if len(o.failed) != 1 || o.failed[0] != "Bad" {
+		t.Fatalf("unexpected failed migrations %v", o.failed)
+	}
+
+	if len(o.reverted) != 1 || o.reverted[0] != "Good" {
+		t.Fatalf("unexpected reverted migrations %v", o.reverted)
+	}
+}
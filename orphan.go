@@ -0,0 +1,39 @@
+package migra
+
+import "context"
+
+// OrphanCheck returns the tables of the database which are not in expected, ordered by name, excluding migra's own tables.
+// Tables are named by schema and table, such as public.users. Comparing the tables after PopAll with the tables
+// before any migration was pushed reveals tables which the down sql of the migrations failed to drop.
+func (m *Migra) OrphanCheck(ctx context.Context, expected []string) ([]string, error) {
+	rows, err := m.execer().QueryContext(ctx, `SELECT n.nspname || '.' || c.relname
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND `+userRelations+`
+		ORDER BY 1`, m.ownTables()...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	known := make(map[string]bool, len(expected))
+	for _, table := range expected {
+		known[table] = true
+	}
+
+	orphans := make([]string, 0)
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+
+		if !known[table] {
+			orphans = append(orphans, table)
+		}
+	}
+
+	return orphans, rows.Err()
+}
@@ -0,0 +1,266 @@
+package migra
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// Parser parses migration files of a format, see RegisterParser
+type Parser interface {
+	Parse(name string, r io.Reader) (*Migration, error)
+}
+
+// manyParser is implemented by parsers whose files may define a list of migrations, see LoadFileMany
+type manyParser interface {
+	ParseMany(name string, r io.Reader) ([]Migration, error)
+}
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = make(map[string]Parser)
+)
+
+// builtinFormats are the extensions parsed by the built-in parser. Other formats supported by viper, such as env and ini files,
+// are flat key value formats which can not express migrations, so files with those extensions in a migration directory are ignored.
+var builtinFormats = []string{"yaml", "yml", "json", "toml"}
+
+func init() {
+	for _, ext := range builtinFormats {
+		parsers[ext] = viperParser{}
+	}
+}
+
+// RegisterParser makes migration files with the extension ext, given without the leading dot, loadable using p.
+// Registering an extension which already has a parser replaces it, including the built-in parsers for formats such as yaml, json and toml.
+func RegisterParser(ext string, p Parser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	parsers[strings.TrimPrefix(ext, ".")] = p
+}
+
+// SetDecodeHook sets a hook run by the built-in parsers when the migration files pushed by this Migra, such as with PushDir, PushFS or PushFile,
+// are decoded into migrations, ahead of the default hooks which decode durations and comma separated lists. It allows loading files whose keys
+// or types differ from the defaults, for example by renaming the keys of the map decoded into a Migration. The types of the values which
+// the hook leaves unchanged are still checked. Files loaded by a source use the DecodeHook of the source instead. A nil hook restores the defaults.
+func (m *Migra) SetDecodeHook(hook mapstructure.DecodeHookFunc) *Migra {
+	m.decodeHook = hook
+	return m
+}
+
+// decodeHooks returns the hook used by the built-in parsers to decode migrations, running hook, if not nil, ahead of the defaults
+func decodeHooks(hook mapstructure.DecodeHookFunc) mapstructure.DecodeHookFunc {
+	hooks := []mapstructure.DecodeHookFunc{
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	}
+
+	if hook != nil {
+		hooks = append([]mapstructure.DecodeHookFunc{hook}, hooks...)
+	}
+
+	return mapstructure.ComposeDecodeHookFunc(hooks...)
+}
+
+// unmapped returns the settings which hook leaves unchanged when they are decoded into a Migration, whose types can be checked
+func unmapped(settings map[string]any, hook mapstructure.DecodeHookFunc) (map[string]any, error) {
+	if hook == nil {
+		return settings, nil
+	}
+
+	// the hook may modify the map it is given
+	copied := make(map[string]any, len(settings))
+	for key, value := range settings {
+		copied[key] = value
+	}
+
+	out, err := mapstructure.DecodeHookExec(hook, reflect.ValueOf(copied), reflect.ValueOf(Migration{}))
+	if err != nil {
+		return nil, err
+	}
+
+	mapped, ok := out.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+
+	kept := make(map[string]any, len(settings))
+	for key, value := range settings {
+		if v, ok := mapped[key]; ok && reflect.DeepEqual(v, value) {
+			kept[key] = value
+		}
+	}
+
+	return kept, nil
+}
+
+// parserFor returns the parser registered for the extension of filename, which decodes with hook if it is the built-in parser
+func parserFor(filename string, hook mapstructure.DecodeHookFunc) (Parser, error) {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+
+	ext := strings.TrimPrefix(path.Ext(filename), ".")
+	p, ok := parsers[ext]
+	if !ok {
+		return nil, fmt.Errorf("no parser registered for %q files: %s", ext, filename)
+	}
+
+	if vp, ok := p.(viperParser); ok {
+		vp.hook = hook
+		return vp, nil
+	}
+
+	return p, nil
+}
+
+// parseMany parses the migrations of a file, which is a single migration unless the parser supports lists
+func parseMany(name string, r io.Reader, hook mapstructure.DecodeHookFunc) ([]Migration, error) {
+	p, err := parserFor(name, hook)
+	if err != nil {
+		return nil, err
+	}
+
+	if mp, ok := p.(manyParser); ok {
+		return mp.ParseMany(name, r)
+	}
+
+	migration, err := p.Parse(name, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return []Migration{*migration}, nil
+}
+
+// viperParser is the built-in parser for the formats supported by viper, decoding with hook, see SetDecodeHook
+type viperParser struct {
+	hook mapstructure.DecodeHookFunc
+}
+
+func (p viperParser) Parse(name string, r io.Reader) (*Migration, error) {
+	v, err := readConfig(name, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeMigration("", v.AllSettings(), p.hook)
+}
+
+// ParseMany parses either a single migration or the list under the migrations key
+func (p viperParser) ParseMany(name string, r io.Reader) ([]Migration, error) {
+	v, err := readConfig(name, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !v.IsSet("migrations") {
+		migration, err := decodeMigration("", v.AllSettings(), p.hook)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Migration{*migration}, nil
+	}
+
+	var items []any
+	switch list := v.Get("migrations").(type) {
+	case []any:
+		items = list
+	case []map[string]any:
+		for _, item := range list {
+			items = append(items, item)
+		}
+	default:
+		return nil, fmt.Errorf("field migrations must be a list, got %v", list)
+	}
+
+	migrations := make([]Migration, len(items))
+	for i, item := range items {
+		settings, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("field migrations[%d] must be a map, got %v", i, item)
+		}
+
+		migration, err := decodeMigration(fmt.Sprintf("migrations[%d].", i), settings, p.hook)
+		if err != nil {
+			return nil, err
+		}
+
+		migrations[i] = *migration
+	}
+
+	return migrations, nil
+}
+
+// decodeMigration decodes the settings of a migration with hook, if not nil, naming the fields in errors with prefix.
+// The types of the values are checked, except for those which the hook maps to other keys or types, see SetDecodeHook.
+func decodeMigration(prefix string, settings map[string]any, hook mapstructure.DecodeHookFunc) (*Migration, error) {
+	checked, err := unmapped(settings, hook)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkFields(prefix, checked); err != nil {
+		return nil, err
+	}
+
+	var migration Migration
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           &migration,
+		WeaklyTypedInput: true,
+		DecodeHook:       decodeHooks(hook),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := decoder.Decode(settings); err != nil {
+		return nil, err
+	}
+
+	if migration.Name == "" {
+		return nil, fmt.Errorf("field %sname is required", prefix)
+	}
+
+	return &migration, nil
+}
+
+// readConfig reads r into viper using the extension of name as config type
+func readConfig(name string, r io.Reader) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType(strings.TrimPrefix(path.Ext(name), "."))
+	if err := v.ReadConfig(r); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SupportedFormats returns the file extensions of migration files for which a parser is registered, without the leading dot.
+// Files with other extensions are ignored when loading migrations from a directory.
+func SupportedFormats() []string {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+
+	formats := make([]string, 0, len(parsers))
+	for ext := range parsers {
+		formats = append(formats, ext)
+	}
+
+	sort.Strings(formats)
+	return formats
+}
+
+// isSupported reports whether a parser is registered for the extension of the file
+func isSupported(filename string) bool {
+	_, err := parserFor(filename, nil)
+	return err == nil
+}
@@ -0,0 +1,101 @@
+package migra_test
+
+import (
+	"context"
+	"io"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cristosal/migra"
+)
+
+// upParser parses files containing only up sql, naming the migration after the file
+type upParser struct{}
+
+func (upParser) Parse(name string, r io.Reader) (*migra.Migration, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &migra.Migration{
+		Name: strings.TrimSuffix(path.Base(name), path.Ext(name)),
+		Up:   string(b),
+	}, nil
+}
+
+func TestRegisterParser(t *testing.T) {
+	migra.RegisterParser("up", upParser{})
+
+	dir := t.TempDir()
+	writeFile(t, dir, "1_first.yml", "name: first\nup: SELECT 1")
+	writeFile(t, dir, "2_second.up", "SELECT 2")
+
+	migrations, err := migra.LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 2 || migrations[1].Name != "2_second" || migrations[1].Up != "SELECT 2" {
+		t.Fatalf("expected migration parsed by custom parser, got %v", migrations)
+	}
+
+	if !strings.Contains(strings.Join(migra.SupportedFormats(), ","), "up") {
+		t.Fatal("expected registered extension to be supported")
+	}
+}
+
+func TestSetDecodeHook(t *testing.T) {
+	// renames the keys of files using sql_up and sql_down
+	hook := func(from, to reflect.Type, data any) (any, error) {
+		m, ok := data.(map[string]any)
+		if !ok || to != reflect.TypeOf(migra.Migration{}) {
+			return data, nil
+		}
+
+		for key, field := range map[string]string{"sql_up": "up", "sql_down": "down"} {
+			if v, ok := m[key]; ok {
+				m[field] = v
+				delete(m, key)
+			}
+		}
+
+		return m, nil
+	}
+
+	dir := t.TempDir()
+	writeFile(t, dir, "1_first.yml", "name: first\nsql_up: SELECT 1\nsql_down: SELECT 2\ntags: a,b")
+
+	migrations, err := migra.DirSource{Path: dir, DecodeHook: hook}.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 1 || migrations[0].Up != "SELECT 1" || migrations[0].Down != "SELECT 2" {
+		t.Fatalf("expected keys renamed by the decode hook, got %v", migrations)
+	}
+
+	if len(migrations[0].Tags) != 2 {
+		t.Fatalf("expected default hooks to split tags, got %v", migrations[0].Tags)
+	}
+
+	// the hook only applies to the loads it is given to
+	migrations, err = migra.LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if migrations[0].Up != "" {
+		t.Fatalf("expected keys not to be renamed without the hook, got %v", migrations)
+	}
+
+	// values the hook does not map are still type checked
+	other := t.TempDir()
+	writeFile(t, other, "1_first.yml", "name: first\nsql_up: SELECT 1\nirreversible: maybe")
+
+	if _, err := (migra.DirSource{Path: other, DecodeHook: hook}).Load(context.Background()); err == nil || !strings.Contains(err.Error(), "irreversible") {
+		t.Fatalf("expected irreversible to be type checked got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package migra
+
+import "context"
+
+// Plan lists the migrations which applying a source would push and the migrations of the source which were already applied
+type Plan struct {
+	Pending []PlanEntry `json:"pending"`
+	Applied []PlanEntry `json:"applied"`
+}
+
+// PlanEntry is a migration of a Plan with the checksum of its sql, see Migration.ComputeChecksum
+type PlanEntry struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Checksum    string `json:"checksum"`
+}
+
+// Plan reports which migrations of src would be pushed by Apply without modifying the database.
+// Pending migrations are in source order and exclude migrations outside of the environment, see SetEnvironment.
+// Applied migrations are ordered by position and have the checksum recorded when they were pushed.
+func (m *Migra) Plan(ctx context.Context, src []Migration) (*Plan, error) {
+	report, err := m.Status(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := Plan{
+		Pending: make([]PlanEntry, 0, len(report.Pending)),
+		Applied: make([]PlanEntry, 0, len(report.Applied)),
+	}
+
+	for i := range report.Pending {
+		mig := &report.Pending[i]
+		if mig.InEnvironment(m.environment) {
+			plan.Pending = append(plan.Pending, PlanEntry{mig.Name, mig.Description, mig.ComputeChecksum()})
+		}
+	}
+
+	for _, mig := range report.Applied {
+		plan.Applied = append(plan.Applied, PlanEntry{mig.Name, mig.Description, mig.Checksum})
+	}
+
+	return &plan, nil
+}
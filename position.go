@@ -0,0 +1,133 @@
+package migra
+
+import (
+	"context"
+	"fmt"
+)
+
+// Reposition moves the migration with the given name to newPosition without executing any migrations.
+// Positions of all migrations are renumbered starting from 1 so that they remain contiguous and unique.
+// newPosition must be between 1 and the number of migrations.
+func (m *Migra) Reposition(ctx context.Context, name string, newPosition int64) error {
+	name = m.normalizeName(name)
+	tx, err := m.begin(ctx, m.db)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	stmt := m.query("SELECT {name} FROM {table} ORDER BY {position} ASC, {id} ASC")
+	rows, err := tx.QueryContext(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	var (
+		names []string
+		found bool
+	)
+
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if n == name {
+			found = true
+			continue
+		}
+
+		names = append(names, n)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("%w: %s", ErrNoMigration, name)
+	}
+
+	if newPosition < 1 || newPosition > int64(len(names)+1) {
+		return fmt.Errorf("position %d is out of range 1 to %d", newPosition, len(names)+1)
+	}
+
+	i := newPosition - 1
+	names = append(names[:i], append([]string{name}, names[i:]...)...)
+
+	if err := m.renumber(ctx, tx, names); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Compact renumbers the positions of all migrations starting from 1 in their current order,
+// removing the gaps left by migrations that were removed from the middle of the history.
+func (m *Migra) Compact(ctx context.Context) error {
+	tx, err := m.begin(ctx, m.db)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	stmt := m.query("SELECT {name} FROM {table} ORDER BY {position} ASC, {id} ASC")
+	rows, err := tx.QueryContext(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			rows.Close()
+			return err
+		}
+
+		names = append(names, n)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := m.renumber(ctx, tx, names); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// renumber sets the positions of the named migrations to 1..n in the given order
+func (m *Migra) renumber(ctx context.Context, tx Execer, names []string) error {
+	stmt := m.query("UPDATE {table} SET {position} = $1 WHERE {name} = $2")
+	for i, n := range names {
+		if _, err := tx.ExecContext(ctx, stmt, i+1, n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NextPosition returns the position following the latest migration, which is 1 when there are no migrations.
+// It is useful for numbering newly generated migration files.
+func (m *Migra) NextPosition(ctx context.Context) (int64, error) {
+	var (
+		next int64
+		stmt = m.query("SELECT COALESCE(MAX({position}), 0) + 1 FROM {table}")
+	)
+
+	if err := m.execer().QueryRowContext(ctx, stmt).Scan(&next); err != nil {
+		return 0, err
+	}
+
+	return next, nil
+}
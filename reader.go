@@ -0,0 +1,125 @@
+package migra
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+)
+
+// PushReader pushes a migration whose up sql is read from a reader, which is useful for large data migrations.
+// The up sql is executed one statement at a time as it is read, so it is never held in memory as a whole.
+//
+// Unlike Push, the up sql is not stored in the migration table. Only the checksum of the up and down sql is recorded,
+// which means the migration's up sql can not be recovered from the database. The down sql is stored as usual
+// so that the migration can be popped, down may be nil for migrations that have no down sql.
+func (m *Migra) PushReader(ctx context.Context, name string, up io.Reader, down io.Reader) error {
+	if name == "" {
+		return errors.New("migration name is required")
+	}
+
+	if up == nil {
+		return errors.New("up sql is required")
+	}
+
+	name = m.normalizeName(name)
+
+	var downSQL string
+	if down != nil {
+		b, err := io.ReadAll(down)
+		if err != nil {
+			return err
+		}
+
+		downSQL = string(b)
+	}
+
+	var (
+		elapsed time.Duration
+		applied bool
+	)
+
+	err := m.withDistributedLock(ctx, func(ctx context.Context) (err error) {
+		elapsed, applied, err = m.executeReader(ctx, name, up, downSQL)
+		return err
+	})
+
+	if err != nil {
+		m.observe().MigrationFailed(name, err)
+		return err
+	}
+
+	if applied {
+		m.observe().MigrationApplied(name, elapsed)
+	}
+
+	return nil
+}
+
+// executeReader records the migration and executes the up sql read from up one statement at a time,
+// reporting whether it was applied or skipped because it was already pushed
+func (m *Migra) executeReader(ctx context.Context, name string, up io.Reader, downSQL string) (time.Duration, bool, error) {
+	tx, err := m.begin(ctx, m.db)
+	if err != nil {
+		return 0, false, err
+	}
+
+	defer tx.Rollback()
+
+	if m.pushed(ctx, tx, name) {
+		return 0, false, nil
+	}
+
+	if err := m.checkTableAccess(name, downSQL); err != nil {
+		return 0, false, err
+	}
+
+	_, recordedDown := m.recordedSQL("", downSQL)
+	sql := m.query("INSERT INTO {table} ({name}, {description}, {up}, {down}, {key}) VALUES ($1, '', NULL, $2, $3)")
+	if _, err := tx.ExecContext(ctx, sql, name, recordedDown, migrationKey(name)); err != nil {
+		return 0, false, err
+	}
+
+	var (
+		h     = sha256.New()
+		stmts = newStatementReader(io.TeeReader(up, h))
+		start = time.Now()
+	)
+
+	for i := 1; ; i++ {
+		stmt, err := stmts.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		if err := m.checkTableAccess(name, stmt); err != nil {
+			return 0, false, err
+		}
+
+		if err := m.execStatement(ctx, tx, name, i, stmt); err != nil {
+			return 0, false, err
+		}
+	}
+
+	h.Write([]byte{0})
+	io.WriteString(h, downSQL)
+
+	elapsed := time.Since(start)
+
+	sql = m.query("UPDATE {table} SET {migrated_at} = NOW(), {checksum} = $2, {duration_ms} = $3, {status} = 'applied' WHERE {name} = $1")
+	if _, err := tx.ExecContext(ctx, sql, name, hex.EncodeToString(h.Sum(nil)), elapsed.Milliseconds()); err != nil {
+		return 0, false, err
+	}
+
+	if err := m.captureSchema(ctx, tx); err != nil {
+		return 0, false, err
+	}
+
+	return elapsed, true, tx.Commit()
+}
@@ -0,0 +1,105 @@
+package migra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Rename describes a source migration which was not applied under its name,
+// but has the same content as an applied migration which is no longer in the source
+type Rename struct {
+	From string
+	To   string
+}
+
+// Reconciliation compares source migrations with the applied migrations by content, see Reconcile
+type Reconciliation struct {
+	New     []Migration        // New are the source migrations which were not applied under any name
+	Edited  []ChecksumMismatch // Edited are the applied migrations whose source changed, except repeatable ones and those allowing reruns
+	Renamed []Rename           // Renamed are the source migrations which were applied under another name
+}
+
+// Ambiguous reports whether the source has edited or renamed migrations, which a push would either skip or apply again
+func (r *Reconciliation) Ambiguous() bool {
+	return len(r.Edited) > 0 || len(r.Renamed) > 0
+}
+
+// Err returns an error wrapping ErrAmbiguousMigration describing the edited and renamed migrations, or nil when there are none
+func (r *Reconciliation) Err() error {
+	var errs []error
+	for _, e := range r.Edited {
+		errs = append(errs, fmt.Errorf("%w: migration %s was edited after it was applied", ErrAmbiguousMigration, e.Name))
+	}
+
+	for _, rn := range r.Renamed {
+		errs = append(errs, fmt.Errorf("%w: migration %s has the content of applied migration %s, was it renamed?", ErrAmbiguousMigration, rn.To, rn.From))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Reconcile uses checksums to tell new source migrations apart from applied migrations which were edited or renamed.
+// Applied migrations recorded without a checksum are only matched by name.
+func (m *Migra) Reconcile(ctx context.Context, source []Migration) (*Reconciliation, error) {
+	inSource := make(map[string]bool, len(source))
+	for i := range source {
+		inSource[m.normalizeName(source[i].Name)] = true
+	}
+
+	var (
+		applied = make(map[string]string)
+		removed = make(map[string]string)
+	)
+
+	err := m.Each(ctx, func(mig Migration) error {
+		// failed migrations are pushed again, so they may have been edited to fix them
+		if mig.Status == StatusFailed {
+			return nil
+		}
+
+		applied[mig.Name] = mig.Checksum
+		if !inSource[mig.Name] && mig.Checksum != "" && !m.isBaseline(mig.Name) {
+			removed[mig.Checksum] = mig.Name
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var r Reconciliation
+	for i := range source {
+		var (
+			mig      = &source[i]
+			computed = mig.ComputeChecksum()
+		)
+
+		recorded, ok := applied[m.normalizeName(mig.Name)]
+		switch {
+		case ok && recorded != "" && recorded != computed && !mig.Repeatable && !mig.AllowRerun:
+			r.Edited = append(r.Edited, ChecksumMismatch{Name: mig.Name, Recorded: recorded, Computed: computed})
+		case ok:
+		case removed[computed] != "":
+			r.Renamed = append(r.Renamed, Rename{From: removed[computed], To: mig.Name})
+		default:
+			r.New = append(r.New, *mig)
+		}
+	}
+
+	return &r, nil
+}
+
+// CheckReconciled reconciles source with the applied migrations and returns an error wrapping ErrAmbiguousMigration
+// when migrations were edited or renamed after being applied, see Reconcile. Apply, and the directory pushes using it,
+// check the loaded migrations before pushing anything.
+func (m *Migra) CheckReconciled(ctx context.Context, source []Migration) error {
+	r, err := m.Reconcile(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	return r.Err()
+}
@@ -0,0 +1,89 @@
+package migra
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// reapply executes a pushed migration again. Migrations which allow reruns are executed on every push,
+// while repeatable migrations are only executed when their checksum differs from the recorded one,
+// after executing the recorded down sql if any, or the down sql of the incoming migration when sql is not stored, see SetStoreSQL. The recorded sql, checksum and time of migration
+// are updated, and whether the migration was executed is reported.
+func (m *Migra) reapply(ctx context.Context, tx Execer, name string, migration *Migration) (time.Duration, bool, error) {
+	checksum := migration.ComputeChecksum()
+
+	if err := m.execSession(ctx, tx, name, migration.SessionSQL); err != nil {
+		return 0, false, err
+	}
+
+	var teardown string
+	if !migration.AllowRerun {
+		var (
+			recorded sql.NullString
+			stored   sql.NullString
+			stmt     = m.query("SELECT {checksum}, {down} FROM {table} WHERE {name} = $1")
+		)
+
+		if err := tx.QueryRowContext(ctx, stmt, name).Scan(&recorded, &stored); err != nil {
+			return 0, false, err
+		}
+
+		if recorded.String == checksum {
+			return 0, false, nil
+		}
+
+		// the recorded down sql tears down what was applied, the incoming down sql is only used when sql is not stored
+		teardown = migration.Down
+		if stored.Valid {
+			teardown = stored.String
+		}
+	}
+
+	if err := m.checkAccess(name, migration); err != nil {
+		return 0, false, err
+	}
+
+	if err := m.checkDDL(name, migration.Up); err != nil {
+		return 0, false, err
+	}
+
+	if teardown != "" {
+		if _, err := tx.ExecContext(ctx, teardown); err != nil {
+			return 0, false, wrapExecError(name, 0, teardown, err)
+		}
+	}
+
+	start := time.Now()
+	if err := m.execUp(ctx, tx, name, migration.Up); err != nil {
+		return 0, false, err
+	}
+
+	elapsed := time.Since(start)
+
+	if err := m.postCheck(ctx, tx, name, migration); err != nil {
+		return 0, false, err
+	}
+
+	meta, err := encodeMeta(migration.Meta)
+	if err != nil {
+		return 0, false, err
+	}
+
+	session, err := encodeSessionSQL(migration.SessionSQL)
+	if err != nil {
+		return 0, false, err
+	}
+
+	up, down := m.recordedSQL(migration.Up, migration.Down)
+	stmt := m.query("UPDATE {table} SET {description} = $2, {up} = $3, {down} = $4, {checksum} = $5, {migrated_at} = NOW(), {duration_ms} = $6, {meta} = $7, {session_sql} = $8, {status} = 'applied' WHERE {name} = $1")
+	if _, err := tx.ExecContext(ctx, stmt, name, migration.Description, up, down, checksum, elapsed.Milliseconds(), meta, session); err != nil {
+		return 0, false, err
+	}
+
+	if err := m.captureSchema(ctx, tx); err != nil {
+		return 0, false, err
+	}
+
+	return elapsed, true, nil
+}
@@ -0,0 +1,64 @@
+package migra
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Resume pushes the migrations of src as a batch, see PushMany, reporting which migrations were applied by this run
+// and which were skipped because they were already applied, such as by an earlier run that was interrupted.
+// When pushing fails the migrations applied before the failure are still reported.
+func (m *Migra) Resume(ctx context.Context, src []Migration) (applied []string, skipped []string, err error) {
+	present := make(map[string]bool, len(src))
+	for i := range src {
+		name := m.normalizeName(src[i].Name)
+		present[name] = m.pushed(ctx, m.execer(), name)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	o := &appliedObserver{next: m.observe(), applied: make(map[string]bool)}
+	resumed := *m
+	resumed.observer = o
+
+	err = resumed.PushMany(ctx, src)
+
+	applied = make([]string, 0)
+	skipped = make([]string, 0)
+	for i := range src {
+		name := m.normalizeName(src[i].Name)
+		if o.applied[name] {
+			applied = append(applied, name)
+		} else if present[name] {
+			skipped = append(skipped, name)
+		}
+	}
+
+	return applied, skipped, err
+}
+
+// appliedObserver records the names of the applied migrations
+type appliedObserver struct {
+	next    Observer
+	mu      sync.Mutex
+	applied map[string]bool
+}
+
+func (o *appliedObserver) MigrationApplied(name string, dur time.Duration) {
+	o.next.MigrationApplied(name, dur)
+
+	o.mu.Lock()
+	o.applied[name] = true
+	o.mu.Unlock()
+}
+
+func (o *appliedObserver) MigrationFailed(name string, err error) {
+	o.next.MigrationFailed(name, err)
+}
+
+func (o *appliedObserver) MigrationReverted(name string) {
+	o.next.MigrationReverted(name)
+}
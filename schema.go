@@ -0,0 +1,133 @@
+package migra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrNoSchemaSnapshot is returned by SchemaDrift when no schema snapshot has been recorded
+var ErrNoSchemaSnapshot = errors.New("no schema snapshot recorded")
+
+// Execer executes sql, it is implemented by *sql.DB, *sql.Conn and *sql.Tx. See SetExecMiddleware.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// SetTrackSchema enables recording a snapshot of the database schema after each push and pop.
+// The snapshot lists every table and column found in information_schema and is stored in the schema table,
+// which is created by CreateMigrationTable when tracking is enabled. See SchemaDrift.
+func (m *Migra) SetTrackSchema(track bool) *Migra {
+	m.trackSchema = track
+	return m
+}
+
+// SchemaTable returns the fully qualified name of the table where schema snapshots are stored
+func (m *Migra) SchemaTable() string {
+	return m.MigrationTable() + "_schema"
+}
+
+// SchemaDrift compares the live database schema with the last recorded snapshot and returns the differences.
+// Columns which were added since the snapshot are prefixed with "+" and removed columns are prefixed with "-".
+// An empty result means the schema has not changed outside of migra.
+func (m *Migra) SchemaDrift(ctx context.Context) ([]string, error) {
+	var recorded string
+
+	stmt := fmt.Sprintf("SELECT fingerprint FROM %s ORDER BY id DESC LIMIT 1", m.SchemaTable())
+	if err := m.execer().QueryRowContext(ctx, stmt).Scan(&recorded); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSchemaSnapshot
+		}
+
+		return nil, err
+	}
+
+	live, err := m.schemaFingerprint(ctx, m.wrap(m.db))
+	if err != nil {
+		return nil, err
+	}
+
+	return diffLines(strings.Split(recorded, "\n"), live), nil
+}
+
+// captureSchema records a snapshot of the current schema when schema tracking is enabled
+func (m *Migra) captureSchema(ctx context.Context, ex Execer) error {
+	if !m.trackSchema {
+		return nil
+	}
+
+	lines, err := m.schemaFingerprint(ctx, ex)
+	if err != nil {
+		return err
+	}
+
+	stmt := fmt.Sprintf("INSERT INTO %s (fingerprint) VALUES ($1)", m.SchemaTable())
+	_, err = ex.ExecContext(ctx, stmt, strings.Join(lines, "\n"))
+	return err
+}
+
+// schemaFingerprint returns a sorted line per column of every user table, excluding migra's own tables
+func (m *Migra) schemaFingerprint(ctx context.Context, ex Execer) ([]string, error) {
+	rows, err := ex.QueryContext(ctx, `SELECT table_schema, table_name, column_name, data_type
+		FROM information_schema.columns
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+		AND NOT (table_schema = $1 AND table_name IN ($2, $3, $4))
+		ORDER BY table_schema, table_name, column_name`, m.ownTables()...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var schema, table, column, dataType string
+		if err := rows.Scan(&schema, &table, &column, &dataType); err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, fmt.Sprintf("%s.%s.%s %s", schema, table, column, dataType))
+	}
+
+	return lines, rows.Err()
+}
+
+// diffLines returns the lines of b which are not in a prefixed with "+" and the lines of a which are not in b prefixed with "-"
+func diffLines(a, b []string) []string {
+	var (
+		inA  = make(map[string]bool)
+		inB  = make(map[string]bool)
+		diff []string
+	)
+
+	for _, line := range a {
+		if line != "" {
+			inA[line] = true
+		}
+	}
+
+	for _, line := range b {
+		inB[line] = true
+		if !inA[line] {
+			diff = append(diff, "+ "+line)
+		}
+	}
+
+	for line := range inA {
+		if !inB[line] {
+			diff = append(diff, "- "+line)
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i][2:] < diff[j][2:]
+	})
+
+	return diff
+}
@@ -0,0 +1,156 @@
+package migra_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cristosal/migra"
+)
+
+func TestSchemaDrift(t *testing.T) {
+	m := getMigra(t)
+	m.SetTrackSchema(true)
+
+	if err := m.CreateMigrationTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		m.DB().Exec("DROP TABLE IF EXISTS test_drift_manual")
+		m.DB().Exec("DROP TABLE IF EXISTS " + m.SchemaTable())
+	})
+
+	if _, err := m.SchemaDrift(ctx); !errors.Is(err, migra.ErrNoSchemaSnapshot) {
+		t.Fatalf("expected ErrNoSchemaSnapshot got %v", err)
+	}
+
+	migration := migra.Migration{
+		Name: "Drift Table",
+		Up:   "CREATE TABLE test_drift(id SERIAL PRIMARY KEY)",
+		Down: "DROP TABLE test_drift",
+	}
+
+	if err := m.Push(ctx, &migration); err != nil {
+		t.Fatal(err)
+	}
+
+	drift, err := m.SchemaDrift(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(drift) != 0 {
+		t.Fatalf("expected no drift after push, got %v", drift)
+	}
+
+	if _, err := m.DB().Exec("CREATE TABLE test_drift_manual(id SERIAL PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+
+	drift, err = m.SchemaDrift(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(drift) != 1 || !strings.HasPrefix(drift[0], "+ ") || !strings.Contains(drift[0], "test_drift_manual.id") {
+		t.Fatalf("expected manual table to be reported as drift, got %v", drift)
+	}
+}
+
+func TestDumpSchema(t *testing.T) {
+	m := getMigra(t)
+	table := m.MigrationTable() + "_dump"
+
+	migration := migra.Migration{
+		Name: "Dump Table",
+		Up: fmt.Sprintf(`CREATE TABLE %[1]s (id SERIAL PRIMARY KEY, email TEXT NOT NULL UNIQUE, created_at TIMESTAMPTZ DEFAULT NOW());
+			CREATE INDEX %[2]s_created_at_idx ON %[1]s (created_at)`, table, m.TableName()),
+		Down: fmt.Sprintf("DROP TABLE %s", table),
+	}
+
+	if err := m.Push(ctx, &migration); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := m.DumpSchema(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dump := buf.String()
+	expected := []string{
+		fmt.Sprintf("CREATE TABLE %s (", table),
+		"email text NOT NULL",
+		"created_at timestamp with time zone DEFAULT now()",
+		"PRIMARY KEY (id)",
+		"UNIQUE (email)",
+		fmt.Sprintf("%s_created_at_idx", m.TableName()),
+	}
+
+	for _, s := range expected {
+		if !strings.Contains(dump, s) {
+			t.Fatalf("expected dump to contain %q got:\n%s", s, dump)
+		}
+	}
+
+	if strings.Contains(dump, fmt.Sprintf("CREATE TABLE %s (", m.MigrationTable())) {
+		t.Fatalf("expected migration table to be excluded from dump got:\n%s", dump)
+	}
+}
+
+func TestSetSchemaDumper(t *testing.T) {
+	m := migra.New(nil).SetSchemaDumper(func(ctx context.Context, db *sql.DB, w io.Writer) error {
+		_, err := io.WriteString(w, "custom")
+		return err
+	})
+
+	var buf bytes.Buffer
+	if err := m.DumpSchema(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "custom" {
+		t.Fatalf("expected custom dumper to be used got %q", buf.String())
+	}
+}
+
+func TestOrphanCheck(t *testing.T) {
+	m := getMigra(t)
+
+	baseline, err := m.OrphanCheck(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		m.DB().Exec("DROP TABLE IF EXISTS test.orphan_kept")
+	})
+
+	err = m.PushMany(ctx, []migra.Migration{
+		{Name: "Dropped", Up: "CREATE TABLE test.orphan_dropped (id INT)", Down: "DROP TABLE test.orphan_dropped"},
+		{Name: "Kept", Up: "CREATE TABLE test.orphan_kept (id INT)", Down: "SELECT 1"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.PopAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	orphans, err := m.OrphanCheck(ctx, baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(orphans) != 1 || orphans[0] != "test.orphan_kept" {
+		t.Fatalf("expected test.orphan_kept to be orphaned got %v", orphans)
+	}
+}
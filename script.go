@@ -0,0 +1,95 @@
+package migra
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PendingSQL returns a script of the up sql of the pending migrations of src in order, see Status, for reviewing or applying them by hand.
+// Each migration is preceded by a comment with its name and description and wrapped in BEGIN and COMMIT,
+// unless it is marked with NoTransaction. The script does not record the migrations in the migration table.
+func (m *Migra) PendingSQL(ctx context.Context, src []Migration) (string, error) {
+	report, err := m.Status(ctx, src)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i := range report.Pending {
+		writeScript(&b, &report.Pending[i], true)
+	}
+
+	return b.String(), nil
+}
+
+// PendingDownSQL returns a script of the down sql of the pending migrations of src in reverse order,
+// which reverts the script returned by PendingSQL. Migrations without down sql are left out.
+func (m *Migra) PendingDownSQL(ctx context.Context, src []Migration) (string, error) {
+	report, err := m.Status(ctx, src)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i := len(report.Pending) - 1; i >= 0; i-- {
+		if report.Pending[i].Down != "" {
+			writeScript(&b, &report.Pending[i], false)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// writeScript writes the header of the migration followed by its up or down sql, wrapped in a transaction unless it is marked with NoTransaction.
+// The batch sql of an up is written as a comment since it is executed repeatedly.
+func writeScript(b *strings.Builder, mig *Migration, up bool) {
+	fmt.Fprintf(b, "-- Migration: %s\n", mig.Name)
+	if mig.Description != "" {
+		writeComment(b, mig.Description)
+	}
+
+	sql := mig.Down
+	if up {
+		sql = mig.Up
+		if mig.Batch != nil {
+			fmt.Fprintf(b, "-- executed with $1 = %d until no rows are affected before the up sql:\n", mig.Batch.Size)
+			writeComment(b, mig.Batch.SQL)
+		}
+	}
+
+	if !mig.NoTransaction {
+		b.WriteString("BEGIN;\n")
+	}
+
+	for _, stmt := range mig.SessionSQL {
+		b.WriteString(terminate(stmt))
+	}
+
+	if sql != "" {
+		b.WriteString(terminate(sql))
+	}
+
+	if !mig.NoTransaction {
+		b.WriteString("COMMIT;\n")
+	}
+
+	b.WriteString("\n")
+}
+
+// writeComment writes each line of s as an sql comment
+func writeComment(b *strings.Builder, s string) {
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		fmt.Fprintf(b, "-- %s\n", strings.TrimSpace(line))
+	}
+}
+
+// terminate trims the sql and ends it with a semicolon and a line break
+func terminate(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if !strings.HasSuffix(sql, ";") {
+		sql += ";"
+	}
+
+	return sql + "\n"
+}
@@ -0,0 +1,47 @@
+package migra
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// execSession executes the session sql of a migration, such as SET LOCAL lock_timeout = '5s',
+// which scopes settings to the transaction of the migration
+func (m *Migra) execSession(ctx context.Context, tx Execer, name string, session []string) error {
+	for _, stmt := range session {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return wrapExecError(name, 0, stmt, err)
+		}
+	}
+
+	return nil
+}
+
+// encodeSessionSQL returns the session sql encoded as json, or nil to store NULL when there is none
+func encodeSessionSQL(session []string) (any, error) {
+	if len(session) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// decodeSessionSQL decodes session sql stored by encodeSessionSQL
+func decodeSessionSQL(stored sql.NullString) ([]string, error) {
+	if !stored.Valid {
+		return nil, nil
+	}
+
+	var session []string
+	if err := json.Unmarshal([]byte(stored.String), &session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
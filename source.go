@@ -0,0 +1,128 @@
+package migra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Source provides the migrations that are pushed by Apply.
+// Implement Source to load migrations from places migra does not support out of the box.
+type Source interface {
+	Load(ctx context.Context) ([]Migration, error)
+}
+
+// SliceSource is a source of migrations defined in code
+type SliceSource []Migration
+
+// Load returns the migrations of the slice
+func (s SliceSource) Load(ctx context.Context) ([]Migration, error) {
+	return s, nil
+}
+
+// FileSource is a source of the migrations defined in a single file, see LoadFileMany
+type FileSource struct {
+	Path       string
+	DecodeHook mapstructure.DecodeHookFunc // DecodeHook is run by the built-in parsers when decoding the file, see SetDecodeHook
+}
+
+// Load reads the migrations from the file
+func (s FileSource) Load(ctx context.Context) ([]Migration, error) {
+	migrations, err := loadFileMany(s.Path, s.DecodeHook)
+	if err != nil {
+		return nil, err
+	}
+
+	return topoSort(migrations)
+}
+
+// DirSource is a source of the migration files inside a directory
+type DirSource struct {
+	Path       string
+	DecodeHook mapstructure.DecodeHookFunc // DecodeHook is run by the built-in parsers when decoding the files, see SetDecodeHook
+}
+
+// Load reads the migration files from the directory
+func (s DirSource) Load(ctx context.Context) ([]Migration, error) {
+	return loadDir(s.Path, s.DecodeHook)
+}
+
+// FSSource is a source of the migration files inside a directory of a filesystem, including subdirectories.
+// When Dir is empty the root of the filesystem is used.
+type FSSource struct {
+	FS         fs.FS
+	Dir        string
+	DecodeHook mapstructure.DecodeHookFunc // DecodeHook is run by the built-in parsers when decoding the files, see SetDecodeHook
+}
+
+// Load reads the migration files from the filesystem
+func (s FSSource) Load(ctx context.Context) ([]Migration, error) {
+	dir := s.Dir
+	if dir == "" {
+		dir = "."
+	}
+
+	return loadDirFS(s.FS, dir, s.DecodeHook)
+}
+
+// Apply loads the migrations from the source and pushes them as a batch, see PushMany.
+// When a lock file is set the migrations are checked against it first, see SetLockFile, and nothing is pushed
+// when migrations were edited or renamed after being applied, see CheckReconciled.
+// and when a baseline is set it is recorded first while holding the distributed lock, if set, see SetBaseline.
+func (m *Migra) Apply(ctx context.Context, src Source) error {
+	migrations, err := m.prepareApply(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	return m.pushMany(ctx, migrations, true)
+}
+
+// ApplyWithTimeout is like Apply but bounds the whole run, loading included, to d, and pushes each migration in its own transaction
+// regardless of SetCheckpointEvery, so the migrations applied before the deadline stay committed. It returns the number of migrations applied by this run.
+// When the deadline is exceeded ErrApplyTimeout is returned, its message including the number of migrations applied.
+func (m *Migra) ApplyWithTimeout(ctx context.Context, src Source, d time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	o := &appliedObserver{next: m.observe(), applied: make(map[string]bool)}
+	bounded := *m
+	bounded.observer = o
+	bounded.checkpointEvery = 1
+
+	migrations, err := bounded.prepareApply(ctx, src)
+	if err == nil {
+		err = bounded.pushMany(ctx, migrations, true)
+	}
+
+	n := len(o.applied)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return n, fmt.Errorf("%w after %s with %d migrations applied: %w", ErrApplyTimeout, d, n, err)
+	}
+
+	return n, err
+}
+
+// prepareApply loads the migrations of src, checks them against the lock, if set, and reconciles them with the applied migrations
+func (m *Migra) prepareApply(ctx context.Context, src Source) ([]Migration, error) {
+	migrations, err := src.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.lockSet {
+		if err := CheckLock(m.lock, migrations); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.CheckReconciled(ctx, migrations); err != nil {
+		return nil, err
+	}
+
+	return migrations, nil
+}
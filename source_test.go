@@ -0,0 +1,79 @@
+package migra_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/cristosal/migra"
+)
+
+type countingSource struct {
+	loads int
+}
+
+func (s *countingSource) Load(ctx context.Context) ([]migra.Migration, error) {
+	s.loads++
+	return []migra.Migration{
+		{Name: "Custom Source", Up: "SELECT 1", Down: "SELECT 1"},
+	}, nil
+}
+
+func TestSources(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "1.yml", `
+name: "first"
+up: "SELECT 1"`)
+	writeFile(t, dir, "2.yml", `
+migrations:
+  - name: "second"
+    up: "SELECT 2"`)
+
+	sources := map[string]migra.Source{
+		"dir":   migra.DirSource{Path: dir},
+		"fs":    migra.FSSource{FS: os.DirFS(dir)},
+		"slice": migra.SliceSource{{Name: "first", Up: "SELECT 1"}, {Name: "second", Up: "SELECT 2"}},
+	}
+
+	for name, src := range sources {
+		migrations, err := src.Load(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if len(migrations) != 2 || migrations[0].Name != "first" || migrations[1].Name != "second" {
+			t.Fatalf("%s: unexpected migrations %v", name, migrations)
+		}
+	}
+
+	migrations, err := migra.FileSource{Path: dir + "/2.yml"}.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 1 || migrations[0].Name != "second" {
+		t.Fatalf("file: unexpected migrations %v", migrations)
+	}
+}
+
+func TestApply(t *testing.T) {
+	m := getMigra(t)
+	src := &countingSource{}
+
+	if err := m.Apply(ctx, src); err != nil {
+		t.Fatal(err)
+	}
+
+	if src.loads != 1 {
+		t.Fatalf("expected source to be loaded once got %d", src.loads)
+	}
+
+	latest, err := m.Latest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest.Name != "Custom Source" {
+		t.Fatalf("expected Custom Source got %s", latest.Name)
+	}
+}
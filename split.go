@@ -0,0 +1,159 @@
+package migra
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// statementReader reads sql statements separated by semicolons from a reader without loading the whole input.
+// Semicolons inside quoted identifiers, string literals, dollar quoted strings and comments do not end a statement.
+type statementReader struct {
+	r *bufio.Reader
+}
+
+func newStatementReader(r io.Reader) *statementReader {
+	return &statementReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next statement without its terminating semicolon.
+// io.EOF is returned once there are no statements left.
+func (s *statementReader) Next() (string, error) {
+	var b strings.Builder
+
+	for {
+		r, _, err := s.r.ReadRune()
+		if err == io.EOF {
+			if stmt := strings.TrimSpace(b.String()); stmt != "" {
+				return stmt, nil
+			}
+
+			return "", io.EOF
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case ';':
+			if stmt := strings.TrimSpace(b.String()); stmt != "" {
+				return stmt, nil
+			}
+
+			b.Reset()
+		case '\'', '"':
+			b.WriteRune(r)
+			if err := s.readUntil(&b, string(r)); err != nil {
+				return "", err
+			}
+		case '-':
+			b.WriteRune(r)
+			if s.accept(&b, '-') {
+				if err := s.readUntil(&b, "\n"); err != nil {
+					return "", err
+				}
+			}
+		case '/':
+			b.WriteRune(r)
+			if s.accept(&b, '*') {
+				if err := s.readUntil(&b, "*/"); err != nil {
+					return "", err
+				}
+			}
+		case '$':
+			b.WriteRune(r)
+			tag, err := s.readDollarTag(&b)
+			if err != nil {
+				return "", err
+			}
+
+			if tag != "" {
+				if err := s.readUntil(&b, tag); err != nil {
+					return "", err
+				}
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+// accept consumes the next rune and writes it to b if it equals want
+func (s *statementReader) accept(b *strings.Builder, want rune) bool {
+	r, _, err := s.r.ReadRune()
+	if err != nil {
+		return false
+	}
+
+	if r != want {
+		s.r.UnreadRune()
+		return false
+	}
+
+	b.WriteRune(r)
+	return true
+}
+
+// readDollarTag is called after a $ was read and returns the full dollar quote tag such as $body$ or $$.
+// An empty tag is returned when the $ does not start a dollar quote, for example a $1 parameter.
+func (s *statementReader) readDollarTag(b *strings.Builder) (string, error) {
+	tag := []rune{'$'}
+
+	for {
+		r, _, err := s.r.ReadRune()
+		if err == io.EOF {
+			return "", nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if r == '$' {
+			b.WriteRune(r)
+			return string(append(tag, r)), nil
+		}
+
+		if !(unicode.IsLetter(r) || r == '_' || (len(tag) > 1 && unicode.IsDigit(r))) {
+			s.r.UnreadRune()
+			return "", nil
+		}
+
+		b.WriteRune(r)
+		tag = append(tag, r)
+	}
+}
+
+// readUntil writes runes to b until the terminator has been written. Reaching the end of input is not an error.
+func (s *statementReader) readUntil(b *strings.Builder, terminator string) error {
+	var (
+		term    = []rune(terminator)
+		matched = 0
+	)
+
+	for matched < len(term) {
+		r, _, err := s.r.ReadRune()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		b.WriteRune(r)
+
+		switch {
+		case r == term[matched]:
+			matched++
+		case r == term[0]:
+			matched = 1
+		default:
+			matched = 0
+		}
+	}
+
+	return nil
+}
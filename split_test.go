@@ -0,0 +1,51 @@
+package migra
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStatementReader(t *testing.T) {
+	input := `
+CREATE TABLE a (id SERIAL PRIMARY KEY, note TEXT DEFAULT 'a;b');
+-- a comment; with a semicolon
+INSERT INTO a (note) VALUES ('it''s; fine');
+/* block; comment */ SELECT "weird;name" FROM a WHERE id = $1;
+CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql;
+DO $$ BEGIN PERFORM 1; END $$;
+;
+SELECT 2`
+
+	expected := []string{
+		"CREATE TABLE a (id SERIAL PRIMARY KEY, note TEXT DEFAULT 'a;b')",
+		"-- a comment; with a semicolon\nINSERT INTO a (note) VALUES ('it''s; fine')",
+		`/* block; comment */ SELECT "weird;name" FROM a WHERE id = $1`,
+		"CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql",
+		"DO $$ BEGIN PERFORM 1; END $$",
+		"SELECT 2",
+	}
+
+	var (
+		r     = newStatementReader(strings.NewReader(input))
+		found []string
+	)
+
+	for {
+		stmt, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found = append(found, stmt)
+	}
+
+	if !reflect.DeepEqual(found, expected) {
+		t.Fatalf("expected %q got %q", expected, found)
+	}
+}
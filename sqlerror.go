@@ -0,0 +1,76 @@
+package migra
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// snippetLength is the maximum number of characters of the failing sql included in a MigrationError message
+const snippetLength = 60
+
+// MigrationError is returned when the sql of a migration fails to execute
+type MigrationError struct {
+	Name      string // Name of the migration
+	Statement int    // Statement is the 1 based index of the failing statement when statements are executed one at a time, otherwise 0
+	SQL       string // SQL is the sql which failed
+	Code      string // Code is the SQLSTATE error code reported by the database, if any
+	Position  int    // Position is the 1 based character position of the error within SQL reported by the database, if any
+	Err       error
+}
+
+func (e *MigrationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "migration %s", e.Name)
+	if e.Statement > 0 {
+		fmt.Fprintf(&b, " statement %d", e.Statement)
+	}
+
+	fmt.Fprintf(&b, ": %v", e.Err)
+	if snippet := e.snippet(); snippet != "" {
+		fmt.Fprintf(&b, " near %q", snippet)
+	}
+
+	return b.String()
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// snippet returns a single line excerpt of the sql surrounding the error position, or its start when the position is unknown
+func (e *MigrationError) snippet() string {
+	s := []rune(strings.Join(strings.Fields(e.SQL), " "))
+	start := 0
+	if e.Position > 0 {
+		// whitespace was collapsed so the position is only approximate
+		start = max(0, min(e.Position-1, len(s))-snippetLength/3)
+	}
+
+	end := min(len(s), start+snippetLength)
+	snippet := string(s[start:end])
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+
+	if end < len(s) {
+		snippet += "..."
+	}
+
+	return snippet
+}
+
+// wrapExecError wraps err from executing the sql of the named migration in a MigrationError
+func wrapExecError(name string, statement int, sql string, err error) error {
+	e := &MigrationError{Name: name, Statement: statement, SQL: sql, Err: err}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		e.Code = pgErr.Code
+		e.Position = int(pgErr.Position)
+	}
+
+	return e
+}
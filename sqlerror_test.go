@@ -0,0 +1,46 @@
+package migra_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cristosal/migra"
+)
+
+func TestMigrationErrorMessage(t *testing.T) {
+	err := &migra.MigrationError{
+		Name:      "Users",
+		Statement: 2,
+		SQL:       "INSERT INTO users (name)\n  VALUES ('a'),, ('b')",
+		Position:  37,
+		Err:       errors.New("syntax error"),
+	}
+
+	msg := err.Error()
+	for _, want := range []string{"migration Users", "statement 2", "syntax error", "VALUES ('a'),, ('b')"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %q in %q", want, msg)
+		}
+	}
+}
+
+func TestPushSQLError(t *testing.T) {
+	m := getMigra(t)
+
+	mig := migra.Migration{Name: "Broken", Up: "SELECT 1; SELEC 2", Down: "SELECT 1"}
+	err := m.Push(ctx, &mig)
+
+	var migErr *migra.MigrationError
+	if !errors.As(err, &migErr) {
+		t.Fatalf("expected MigrationError got %v", err)
+	}
+
+	if migErr.Name != "Broken" || migErr.Code != "42601" {
+		t.Fatalf("unexpected migration error %+v", migErr)
+	}
+
+	if !strings.Contains(err.Error(), "Broken") || !strings.Contains(err.Error(), "SELEC 2") {
+		t.Fatalf("expected migration name and sql in %q", err.Error())
+	}
+}
@@ -0,0 +1,78 @@
+package migra
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// SetOnStatementError executes the up sql of migrations one statement at a time, each within a savepoint.
+// When a statement fails fn decides whether to roll back to the savepoint and continue with the next statement,
+// by returning true, or to abort the migration by returning false. Continued failures are logged, see SetLogger.
+// By default up sql is executed as a whole and any failure aborts the migration.
+func (m *Migra) SetOnStatementError(fn func(stmt string, err error) bool) *Migra {
+	m.onStatementError = fn
+	return m
+}
+
+// execUp executes the up sql of a migration, one statement at a time when a statement error handler is set
+func (m *Migra) execUp(ctx context.Context, tx Execer, name, up string) error {
+	// batched migrations may have no up sql
+	if up == "" {
+		return nil
+	}
+
+	if m.onStatementError == nil {
+		if _, err := tx.ExecContext(ctx, up); err != nil {
+			return wrapExecError(name, 0, up, err)
+		}
+
+		return nil
+	}
+
+	stmts := newStatementReader(strings.NewReader(up))
+	for i := 1; ; i++ {
+		stmt, err := stmts.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := m.execStatement(ctx, tx, name, i, stmt); err != nil {
+			return err
+		}
+	}
+}
+
+// execStatement executes the i-th statement of a migration, within a savepoint when a statement error handler is set
+func (m *Migra) execStatement(ctx context.Context, tx Execer, name string, i int, stmt string) error {
+	if m.onStatementError == nil {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return wrapExecError(name, i, stmt, err)
+		}
+
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT migra_statement"); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, stmt); err != nil {
+		if !m.onStatementError(stmt, err) {
+			return wrapExecError(name, i, stmt, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT migra_statement"); err != nil {
+			return err
+		}
+
+		m.logf("continuing migration %s after statement %d failed: %v", name, i, err)
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT migra_statement")
+	return err
+}
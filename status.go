@@ -0,0 +1,198 @@
+package migra
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// StatusReport compares the migrations of a source with the migrations applied to the database
+type StatusReport struct {
+	Applied []Migration // Applied are the source migrations which were applied, ordered by position
+	Pending []Migration // Pending are the source migrations which were not applied yet, in source order
+	Missing []Migration // Missing are the applied migrations which are not in the source, ordered by position
+}
+
+// Status reports which migrations of source are applied or pending, and which applied migrations are missing from source.
+// The baseline is not reported as missing, see SetBaseline.
+func (m *Migra) Status(ctx context.Context, source []Migration) (*StatusReport, error) {
+	inSource := make(map[string]bool, len(source))
+	for i := range source {
+		inSource[m.normalizeName(source[i].Name)] = true
+	}
+
+	var (
+		report  StatusReport
+		applied = make(map[string]bool)
+	)
+
+	err := m.Each(ctx, func(mig Migration) error {
+		if mig.MigratedAt.IsZero() {
+			return nil
+		}
+
+		applied[mig.Name] = true
+		if inSource[mig.Name] {
+			report.Applied = append(report.Applied, mig)
+		} else if !m.isBaseline(mig.Name) {
+			report.Missing = append(report.Missing, mig)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range source {
+		if !applied[m.normalizeName(source[i].Name)] {
+			report.Pending = append(report.Pending, source[i])
+		}
+	}
+
+	return &report, nil
+}
+
+// MigrationState is the state of a migration in a StatusReport
+type MigrationState int
+
+const (
+	// StateApplied migrations are in the source and were applied to the database
+	StateApplied MigrationState = iota
+
+	// StatePending migrations are in the source and were not applied yet
+	StatePending
+
+	// StateMissing migrations were applied to the database and are not in the source
+	StateMissing
+)
+
+func (s MigrationState) String() string {
+	switch s {
+	case StateApplied:
+		return "applied"
+	case StatePending:
+		return "pending"
+	case StateMissing:
+		return "missing"
+	default:
+		return "unknown"
+	}
+}
+
+// MigrationStatusRow is a migration with its state
+type MigrationStatusRow struct {
+	Migration
+	State MigrationState
+}
+
+// Rows returns the applied and missing migrations ordered by position followed by the pending migrations in source order
+func (r *StatusReport) Rows() []MigrationStatusRow {
+	rows := make([]MigrationStatusRow, 0, len(r.Applied)+len(r.Pending)+len(r.Missing))
+	for _, mig := range r.Applied {
+		rows = append(rows, MigrationStatusRow{mig, StateApplied})
+	}
+
+	for _, mig := range r.Missing {
+		rows = append(rows, MigrationStatusRow{mig, StateMissing})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Position < rows[j].Position
+	})
+
+	for _, mig := range r.Pending {
+		rows = append(rows, MigrationStatusRow{mig, StatePending})
+	}
+
+	return rows
+}
+
+// StatusRows is like Status but returns the migrations of the report as rows with their state, see StatusReport.Rows
+func (m *Migra) StatusRows(ctx context.Context, source []Migration) ([]MigrationStatusRow, error) {
+	report, err := m.Status(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	return report.Rows(), nil
+}
+
+// MigrationStatus is the status of a migration recorded in the migration table
+type MigrationStatus string
+
+const (
+	// StatusPending is recorded while the up sql of a migration is executed in the transaction which pushes it
+	StatusPending MigrationStatus = "pending"
+
+	// StatusApplied is recorded once the up sql of a migration was executed
+	StatusApplied MigrationStatus = "applied"
+
+	// StatusSkipped is recorded for migrations whose up sql was not executed, such as when their precheck is false
+	StatusSkipped MigrationStatus = "skipped"
+
+	// StatusFailed is recorded for migrations which run outside of a transaction, marked with NoTransaction or with a batch spec,
+	// when they fail after part of them may have been applied. Failed migrations are not considered pushed, so pushing them again
+	// retries them, and popping them executes their down sql to clean up. Migrations which fail within a transaction are rolled back
+	// and leave no record.
+	StatusFailed MigrationStatus = "failed"
+)
+
+// recordFailed records a migration which failed outside of a transaction with StatusFailed, returning err joined with any error of recording it
+func (m *Migra) recordFailed(ctx context.Context, c Execer, name string, migration *Migration, err error) error {
+	meta, merr := encodeMeta(migration.Meta)
+	if merr != nil {
+		return errors.Join(err, merr)
+	}
+
+	var (
+		up, down = m.recordedSQL(migration.Up, migration.Down)
+		stmt     = m.query(`INSERT INTO {table} ({name}, {description}, {up}, {down}, {checksum}, {irreversible}, {key}, {no_transaction}, {meta}, {status})
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'failed') ON CONFLICT ({name}) DO UPDATE SET {status} = 'failed'`)
+	)
+
+	// the migration is recorded even when the push was cancelled, since its changes were not rolled back
+	if _, rerr := c.ExecContext(context.WithoutCancel(ctx), stmt, name, migration.Description, up, down, migration.ComputeChecksum(), migration.Irreversible, migrationKey(name), migration.NoTransaction, meta); rerr != nil {
+		return errors.Join(err, rerr)
+	}
+
+	return err
+}
+
+// clearFailed removes the record of a failed migration before it is pushed again, see StatusFailed
+func (m *Migra) clearFailed(ctx context.Context, c Execer, name string) error {
+	_, err := c.ExecContext(ctx, m.query("DELETE FROM {table} WHERE {name} = $1 AND {status} = 'failed'"), name)
+	return err
+}
+
+// DBAhead returns the applied migrations which are not in source and were applied after the last migration in common with source,
+// ordered by position. It is empty when the database is equal to or behind source. A deploy of an older source
+// has to pop these migrations before the source can be applied. The baseline is never ahead, see SetBaseline.
+func (m *Migra) DBAhead(ctx context.Context, source []Migration) ([]Migration, error) {
+	inSource := make(map[string]bool, len(source))
+	for i := range source {
+		inSource[m.normalizeName(source[i].Name)] = true
+	}
+
+	ahead := make([]Migration, 0)
+	err := m.Each(ctx, func(mig Migration) error {
+		if mig.MigratedAt.IsZero() {
+			return nil
+		}
+
+		if inSource[mig.Name] {
+			ahead = ahead[:0]
+		} else if !m.isBaseline(mig.Name) {
+			ahead = append(ahead, mig)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ahead, nil
+}
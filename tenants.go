@@ -0,0 +1,79 @@
+package migra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ApplyToAll applies the migrations to every database given by dsns, such as the databases of tenants sharing the same migrations.
+// Each database is opened with the driver of m, its migration table is created and src is applied, one database after another,
+// using the configuration of m. The error of each dsn is returned in a map keyed by dsn, nil when it succeeded,
+// while the returned error joins the errors of all failed databases. Passwords in dsns are redacted from the errors.
+func (m *Migra) ApplyToAll(ctx context.Context, dsns []string, src []Migration) (map[string]error, error) {
+	var (
+		errs    []error
+		results = make(map[string]error, len(dsns))
+	)
+
+	for _, dsn := range dsns {
+		err := m.applyTo(ctx, dsn, src)
+		if err != nil {
+			err = &dsnError{dsn: dsn, err: err}
+			errs = append(errs, err)
+		}
+
+		results[dsn] = err
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// applyTo opens the database given by dsn with the driver of m and applies src to it
+func (m *Migra) applyTo(ctx context.Context, dsn string, src []Migration) error {
+	db := sql.OpenDB(connector(m.db.Driver(), dsn))
+	defer db.Close()
+
+	tenant := *m
+	tenant.db = db
+	tenant.tx = nil
+
+	if err := tenant.CreateMigrationTable(ctx); err != nil {
+		return err
+	}
+
+	return tenant.Apply(ctx, SliceSource(src))
+}
+
+// dsnError is the error of applying migrations to a database, which redacts the password of the dsn
+type dsnError struct {
+	dsn string
+	err error
+}
+
+func (e *dsnError) Error() string {
+	redacted := redactDSN(e.dsn)
+	return fmt.Sprintf("%s: %s", redacted, strings.ReplaceAll(e.err.Error(), e.dsn, redacted))
+}
+
+func (e *dsnError) Unwrap() error {
+	return e.err
+}
+
+// passwordParam matches the password of a key value connection string
+var passwordParam = regexp.MustCompile(`password=('(?:[^'\\]|\\.)*'|\S*)`)
+
+// redactDSN replaces the password of a url or key value connection string with xxxxx
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+
+	return passwordParam.ReplaceAllString(dsn, "password=xxxxx")
+}
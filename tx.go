@@ -0,0 +1,109 @@
+package migra
+
+import (
+	"context"
+	"database/sql"
+)
+
+// txn is a transaction begun by migra, either a *sql.Tx or a savepoint within the transaction of InTx
+type txn interface {
+	Execer
+	Commit() error
+	Rollback() error
+}
+
+// InTx begins a transaction and calls fn with a Migra bound to it, committing when fn returns nil and rolling back otherwise.
+// Migrations pushed or popped through the bound Migra run within the transaction, so they can be combined atomically
+// with application changes made through Tx. Each migration runs in a savepoint, a failed migration is rolled back
+// to its savepoint and leaves the transaction usable. Observers are notified when a migration's savepoint is released,
+// before the transaction is committed.
+func (m *Migra) InTx(ctx context.Context, fn func(tx *Migra) error) error {
+	if m.tx != nil {
+		return fn(m)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	bound := *m
+	bound.tx = tx
+	if err := fn(&bound); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Tx returns the transaction the Migra is bound to within InTx, or nil outside of it
+func (m *Migra) Tx() *sql.Tx {
+	return m.tx
+}
+
+// execer returns the transaction when bound by InTx and the database otherwise
+func (m *Migra) execer() Execer {
+	if m.tx != nil {
+		return m.wrap(m.tx)
+	}
+
+	return m.wrap(m.db)
+}
+
+// begin begins a transaction on c, or a savepoint when bound to a transaction by InTx
+func (m *Migra) begin(ctx context.Context, c conn) (txn, error) {
+	if m.tx == nil {
+		tx, err := c.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if m.execMiddleware == nil {
+			return tx, nil
+		}
+
+		return &wrappedTx{Execer: m.wrap(tx), tx: tx}, nil
+	}
+
+	ex := m.wrap(m.tx)
+	if _, err := ex.ExecContext(ctx, "SAVEPOINT migra"); err != nil {
+		return nil, err
+	}
+
+	return &savepoint{Execer: ex, ctx: ctx}, nil
+}
+
+// savepoint is a nested transaction within the transaction of InTx
+type savepoint struct {
+	Execer
+	ctx  context.Context
+	done bool
+}
+
+// Commit releases the savepoint
+func (s *savepoint) Commit() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+
+	s.done = true
+	_, err := s.Execer.ExecContext(s.ctx, "RELEASE SAVEPOINT migra")
+	return err
+}
+
+// Rollback rolls back to the savepoint and releases it
+func (s *savepoint) Rollback() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+
+	s.done = true
+	if _, err := s.Execer.ExecContext(s.ctx, "ROLLBACK TO SAVEPOINT migra"); err != nil {
+		return err
+	}
+
+	_, err := s.Execer.ExecContext(s.ctx, "RELEASE SAVEPOINT migra")
+	return err
+}
@@ -0,0 +1,189 @@
+package migra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// SetPrepareCheck enables preparing the up and down sql of each migration against the database during Validate.
+// Preparing parses the sql without executing it, however not every statement can be prepared so this is disabled by default.
+func (m *Migra) SetPrepareCheck(enabled bool) *Migra {
+	m.prepareCheck = enabled
+	return m
+}
+
+// Validate checks migrations before they are pushed.
+// Every migration must have a non-empty unique name and non-empty up sql.
+// If prepare checking is enabled the sql is also parsed by the database.
+// All problems found are joined together in the returned error.
+func (m *Migra) Validate(ctx context.Context, migrations []Migration) error {
+	errs := checkMigrations(migrations)
+	if !m.prepareCheck {
+		return errors.Join(errs...)
+	}
+
+	for i := range migrations {
+		mig := &migrations[i]
+
+		if err := m.prepare(ctx, mig.Up); err != nil {
+			errs = append(errs, fmt.Errorf("migration %s: up sql: %w", mig.Name, err))
+		}
+
+		if err := m.prepare(ctx, mig.Down); err != nil {
+			errs = append(errs, fmt.Errorf("migration %s: down sql: %w", mig.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CheckDir loads the migration files inside a directory and checks them like Validate, without a database.
+// Every file which fails to parse is reported, as well as unknown dependencies and dependency cycles.
+func CheckDir(dirpath string) []error {
+	entries, err := os.ReadDir(dirpath)
+	if err != nil {
+		return []error{err}
+	}
+
+	var (
+		errs       []error
+		migrations []Migration
+	)
+
+	for i := range entries {
+		if entries[i].IsDir() || !isSupported(entries[i].Name()) {
+			continue
+		}
+
+		// errors of loading name the file
+		found, err := loadFileMany(path.Join(dirpath, entries[i].Name()), nil)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		migrations = append(migrations, found...)
+	}
+
+	errs = append(errs, checkMigrations(migrations)...)
+	if _, err := topoSort(migrations); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// ValidateFile loads the migrations of a migration file and checks them like Validate, without a database.
+// Problems are reported with the file and the offending field, such as a missing name or an up which is not a string.
+func ValidateFile(filepath string) error {
+	// dependencies may name migrations of other files, so they are not checked
+	migrations, err := loadFileMany(filepath, nil)
+	if err != nil {
+		return err
+	}
+
+	errs := checkMigrations(migrations)
+	for i := range errs {
+		errs[i] = fmt.Errorf("%s: %w", filepath, errs[i])
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkMigrations returns the problems with the names, up sql and batch specs of the migrations
+func checkMigrations(migrations []Migration) []error {
+	var (
+		errs  []error
+		names = make(map[string]bool)
+	)
+
+	for i := range migrations {
+		mig := &migrations[i]
+
+		if mig.Name == "" {
+			errs = append(errs, fmt.Errorf("migration at index %d: name is required", i))
+		} else if names[mig.Name] {
+			errs = append(errs, fmt.Errorf("migration %s: name is not unique", mig.Name))
+		} else {
+			names[mig.Name] = true
+		}
+
+		if mig.Up == "" && mig.Batch == nil {
+			errs = append(errs, fmt.Errorf("migration %s: up sql is required", mig.Name))
+		}
+
+		if err := mig.Batch.check(mig.Name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// prepare parses the statement on the database without executing it
+func (m *Migra) prepare(ctx context.Context, statement string) error {
+	if statement == "" {
+		return nil
+	}
+
+	stmt, err := m.db.PrepareContext(ctx, statement)
+	if err != nil {
+		return err
+	}
+
+	return stmt.Close()
+}
+
+// undefinedCodes are the sqlstates of statements referencing objects which do not exist,
+// which is expected of down sql validated before its migration was pushed
+var undefinedCodes = map[string]bool{
+	"42P01": true, // undefined_table
+	"42703": true, // undefined_column
+	"42883": true, // undefined_function
+	"42704": true, // undefined_object
+}
+
+// ValidateDowns prepares every statement of the down sql of the migrations against the database without executing it,
+// so rollbacks are known to parse before they are needed. Statements referencing tables, columns, functions or other objects
+// which do not exist yet are skipped with a warning, see SetLogger. Every other failure, such as a syntax error,
+// is returned joined together, and validation stops when the context is done.
+func (m *Migra) ValidateDowns(ctx context.Context, migrations []Migration) error {
+	var errs []error
+	for i := range migrations {
+		mig := &migrations[i]
+		stmts := newStatementReader(strings.NewReader(mig.Down))
+
+		for n := 1; ; n++ {
+			if err := ctx.Err(); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+
+			stmt, err := stmts.Next()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("migration %s: down sql: %w", mig.Name, err))
+				break
+			}
+
+			if err := m.prepare(ctx, stmt); err != nil {
+				merr := wrapExecError(mig.Name, n, stmt, err).(*MigrationError)
+				if undefinedCodes[merr.Code] {
+					m.logf("skipping down sql of migration %s statement %d: %v", mig.Name, n, err)
+					continue
+				}
+
+				errs = append(errs, merr)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
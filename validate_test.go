@@ -0,0 +1,158 @@
+package migra_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cristosal/migra"
+)
+
+func TestValidate(t *testing.T) {
+	m := migra.New(nil)
+
+	valid := []migra.Migration{
+		{Name: "first", Up: "CREATE TABLE first(id SERIAL PRIMARY KEY)"},
+		{Name: "second", Up: "CREATE TABLE second(id SERIAL PRIMARY KEY)"},
+	}
+
+	if err := m.Validate(ctx, valid); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []migra.Migration{
+		{Name: "", Up: "SELECT 1"},
+		{Name: "dup", Up: "SELECT 1"},
+		{Name: "dup", Up: "SELECT 1"},
+		{Name: "no up"},
+	}
+
+	err := m.Validate(ctx, invalid)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	expected := []string{
+		"migration at index 0: name is required",
+		"migration dup: name is not unique",
+		"migration no up: up sql is required",
+	}
+
+	for _, msg := range expected {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("expected error to contain %q, got %q", msg, err.Error())
+		}
+	}
+}
+
+func TestValidatePrepareCheck(t *testing.T) {
+	m := getMigra(t)
+	m.SetPrepareCheck(true)
+
+	migrations := []migra.Migration{
+		{Name: "broken", Up: "CREATE TABL broken(id SERIAL PRIMARY KEY)"},
+	}
+
+	if err := m.Validate(ctx, migrations); err == nil {
+		t.Fatal("expected prepare check to fail")
+	}
+}
+
+func TestValidateDowns(t *testing.T) {
+	m := getMigra(t)
+
+	migrations := []migra.Migration{
+		{Name: "valid", Up: "SELECT 1", Down: "SELECT 1; SELECT 2"},
+		{Name: "missing table", Up: "SELECT 1", Down: "DELETE FROM validate_downs_does_not_exist"},
+		{Name: "broken", Up: "SELECT 1", Down: "SELECT 1; DROP TABL broken"},
+	}
+
+	err := m.ValidateDowns(ctx, migrations)
+
+	var merr *migra.MigrationError
+	if !errors.As(err, &merr) {
+		t.Fatalf("expected migration error got %v", err)
+	}
+
+	if merr.Name != "broken" || merr.Statement != 2 {
+		t.Fatalf("expected statement 2 of broken to fail got %v", merr)
+	}
+
+	if strings.Contains(err.Error(), "missing table") {
+		t.Fatalf("expected missing table to be skipped got %v", err)
+	}
+
+	if err := m.ValidateDowns(ctx, migrations[:2]); err != nil {
+		t.Fatal(err)
+	}
+
+	// failures other than missing objects are not skipped
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := m.ValidateDowns(cancelled, migrations[:1]); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled got %v", err)
+	}
+}
+
+func TestCheckDir(t *testing.T) {
+	clean := t.TempDir()
+	writeFile(t, clean, "1.yml", "name: first\nup: SELECT 1\n")
+	writeFile(t, clean, "2.yml", "name: second\nup: SELECT 2\n")
+
+	if errs := migra.CheckDir(clean); len(errs) != 0 {
+		t.Fatalf("expected clean directory got %v", errs)
+	}
+
+	broken := t.TempDir()
+	writeFile(t, broken, "1.yml", "name: first\nup: SELECT 1\n")
+	writeFile(t, broken, "2.yml", "name: first\nup: SELECT 2\n")
+	writeFile(t, broken, "3.yml", "name: no up\n")
+	writeFile(t, broken, "4.yml", "name: [unterminated\n")
+
+	errs := migra.CheckDir(broken)
+	msg := errors.Join(errs...).Error()
+
+	expected := []string{
+		"4.yml:",
+		"migration first: name is not unique",
+		"migration no up: up sql is required",
+	}
+
+	for _, s := range expected {
+		if !strings.Contains(msg, s) {
+			t.Fatalf("expected errors to contain %q got %q", s, msg)
+		}
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		file, content, expected string
+	}{
+		{"valid.yml", "name: valid\nup: SELECT 1\n", ""},
+		{"no_name.yml", "up: SELECT 1\n", "no_name.yml: field name is required"},
+		{"list_up.yml", "name: list up\nup: [SELECT 1]\n", "list_up.yml: field up must be a string"},
+		{"no_up.yml", "name: no up\n", "no_up.yml: migration no up: up sql is required"},
+		{"many.yml", "migrations:\n  - name: first\n    up: SELECT 1\n  - name: second\n    up: 2\n", "many.yml: field migrations[1].up must be a string"},
+		{"batch.json", `{"name": "batch", "batch": {"sql": "SELECT 1", "batch_size": "ten"}}`, "batch.json: field batch.batch_size must be an integer"},
+	}
+
+	for _, tt := range tests {
+		err := migra.ValidateFile(writeFile(t, dir, tt.file, tt.content))
+		if tt.expected == "" {
+			if err != nil {
+				t.Fatalf("expected %s to be valid got %v", tt.file, err)
+			}
+
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Fatalf("expected %s to fail with %q got %v", tt.file, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package migra
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ChecksumMismatch describes an applied migration whose source no longer matches what was recorded when it was pushed
+type ChecksumMismatch struct {
+	Name     string
+	Recorded string
+	Computed string
+}
+
+// Verify compares the checksums of the source migrations with the checksums recorded for applied migrations
+// and returns the migrations that were edited after being applied.
+// Source migrations which have not been applied, and applied migrations recorded without a checksum, as failed or as the baseline, are ignored.
+func (m *Migra) Verify(ctx context.Context, migrations []Migration) ([]ChecksumMismatch, error) {
+	recorded := make(map[string]string)
+	err := m.Each(ctx, func(mig Migration) error {
+		if mig.Checksum != "" && mig.Status != StatusFailed && !m.isBaseline(mig.Name) {
+			recorded[mig.Name] = mig.Checksum
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []ChecksumMismatch
+	for i := range migrations {
+		checksum, ok := recorded[m.normalizeName(migrations[i].Name)]
+		if !ok {
+			continue
+		}
+
+		if computed := migrations[i].ComputeChecksum(); computed != checksum {
+			mismatches = append(mismatches, ChecksumMismatch{
+				Name:     migrations[i].Name,
+				Recorded: checksum,
+				Computed: computed,
+			})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// SetDetectConflicts makes pushes return ErrMigrationConflict when a migration with the same name was already pushed
+// with different up or down sql, instead of skipping it as already applied. This catches names that were accidentally reused.
+func (m *Migra) SetDetectConflicts(detect bool) *Migra {
+	m.detectConflicts = detect
+	return m
+}
+
+// checkConflict returns ErrMigrationConflict if the migration pushed under name was recorded with different sql
+func (m *Migra) checkConflict(ctx context.Context, c Execer, name string, migration *Migration) error {
+	if !m.detectConflicts {
+		return nil
+	}
+
+	var (
+		checksum, up, down sql.NullString
+		stmt               = m.query("SELECT {checksum}, {up}, {down} FROM {table} WHERE {name} = $1")
+	)
+
+	if err := c.QueryRowContext(ctx, stmt, name).Scan(&checksum, &up, &down); err != nil {
+		return err
+	}
+
+	conflict := checksum.String != migration.ComputeChecksum()
+
+	// migrations recorded before checksums were introduced are compared by their stored sql
+	if checksum.String == "" {
+		conflict = up.Valid && (up.String != migration.Up || down.String != migration.Down)
+	}
+
+	if conflict {
+		return fmt.Errorf("%w: %s", ErrMigrationConflict, name)
+	}
+
+	return nil
+}
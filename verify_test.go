@@ -0,0 +1,75 @@
+package migra_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cristosal/migra"
+)
+
+func TestVerify(t *testing.T) {
+	m := getMigra(t)
+
+	migrations := []migra.Migration{
+		{Name: "First", Up: "SELECT 1", Down: "SELECT 1"},
+		{Name: "Second", Up: "SELECT 2", Down: "SELECT 2"},
+	}
+
+	if err := m.PushMany(ctx, migrations); err != nil {
+		t.Fatal(err)
+	}
+
+	mismatches, err := m.Verify(ctx, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mismatches) != 0 {
+		t.Fatalf("expected no mismatches got %v", mismatches)
+	}
+
+	edited := []migra.Migration{
+		migrations[0],
+		{Name: "Second", Up: "SELECT 22", Down: "SELECT 2"},
+		{Name: "Pending", Up: "SELECT 3"},
+	}
+
+	mismatches, err = m.Verify(ctx, edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mismatches) != 1 || mismatches[0].Name != "Second" {
+		t.Fatalf("expected Second to be reported got %v", mismatches)
+	}
+
+	if mismatches[0].Recorded != migrations[1].ComputeChecksum() || mismatches[0].Computed != edited[1].ComputeChecksum() {
+		t.Fatalf("unexpected checksums %+v", mismatches[0])
+	}
+}
+
+func TestDetectConflicts(t *testing.T) {
+	m := getMigra(t)
+
+	mig := migra.Migration{Name: "First", Up: "SELECT 1", Down: "SELECT 1"}
+	if err := m.Push(ctx, &mig); err != nil {
+		t.Fatal(err)
+	}
+
+	reused := migra.Migration{Name: "First", Up: "SELECT 11", Down: "SELECT 1"}
+
+	// by default the reused name is skipped as already applied
+	if err := m.Push(ctx, &reused); err != nil {
+		t.Fatal(err)
+	}
+
+	m.SetDetectConflicts(true)
+
+	if err := m.Push(ctx, &mig); err != nil {
+		t.Fatalf("expected identical migration to be skipped got %v", err)
+	}
+
+	if err := m.Push(ctx, &reused); !errors.Is(err, migra.ErrMigrationConflict) {
+		t.Fatalf("expected ErrMigrationConflict got %v", err)
+	}
+}